@@ -6,31 +6,26 @@
 package cloudinary
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
-	"github.com/qiscus/qiscus-sdk-api/api/admin/v1"
-)
-
-const (
-	baseAdminUrl = "https://api.cloudinary.com/v1_1"
 )
 
 const (
 	pathListAllImages = "/resources/image"
 	pathListAllRaws   = "/resources/raw"
-	pathListSingleImage = "/resources/image/upload/"
-  pathListAllVideos = "/resources/video"
+	pathListAllVideos = "/resources/video"
 )
 
 const (
 	maxResults = 2048
 )
 
-func (s *Service) dropAllResources(rtype ResourceType, w io.Writer) error {
+func (s *Service) dropAllResources(ctx context.Context, rtype ResourceType, w io.Writer) error {
 	qs := url.Values{
 		"max_results": []string{strconv.FormatInt(maxResults, 10)},
 	}
@@ -39,17 +34,24 @@ func (s *Service) dropAllResources(rtype ResourceType, w io.Writer) error {
 		path = pathListAllRaws
 	}
 	for {
-		resp, err := http.Get(fmt.Sprintf("%s%s?%s", s.adminURI, path, qs.Encode()))
+		resp, err := s.get(ctx, fmt.Sprintf("%s%s?%s", s.adminURI, path, qs.Encode()))
+		if err != nil {
+			return err
+		}
 		m, err := handleHttpResponse(resp)
+		resp.Body.Close()
 		if err != nil {
 			return err
 		}
 		for _, v := range m["resources"].([]interface{}) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			publicId := v.(map[string]interface{})["public_id"].(string)
 			if w != nil {
 				fmt.Fprintf(w, "Deleting %s ... ", publicId)
 			}
-			if err := s.Delete(publicId, "", rtype); err != nil {
+			if err := s.DeleteContext(ctx, publicId, "", rtype); err != nil {
 				// Do not return. Report the error but continue through the list.
 				fmt.Fprintf(w, "Error: %s: %s\n", publicId, err.Error())
 			}
@@ -67,28 +69,47 @@ func (s *Service) dropAllResources(rtype ResourceType, w io.Writer) error {
 // DropAllImages deletes all remote images from Cloudinary. File names are
 // written to io.Writer if available.
 func (s *Service) DropAllImages(w io.Writer) error {
-	return s.dropAllResources(ImageType, w)
+	return s.DropAllImagesContext(context.Background(), w)
+}
+
+// DropAllImagesContext is like DropAllImages but takes a context.
+func (s *Service) DropAllImagesContext(ctx context.Context, w io.Writer) error {
+	return s.dropAllResources(ctx, ImageType, w)
 }
 
 // DropAllRaws deletes all remote raw files from Cloudinary. File names are
 // written to io.Writer if available.
 func (s *Service) DropAllRaws(w io.Writer) error {
-	return s.dropAllResources(RawType, w)
+	return s.DropAllRawsContext(context.Background(), w)
+}
+
+// DropAllRawsContext is like DropAllRaws but takes a context.
+func (s *Service) DropAllRawsContext(ctx context.Context, w io.Writer) error {
+	return s.dropAllResources(ctx, RawType, w)
 }
 
 // DropAll deletes all remote resources (both images and raw files) from Cloudinary.
 // File names are written to io.Writer if available.
 func (s *Service) DropAll(w io.Writer) error {
-	if err := s.DropAllImages(w); err != nil {
+	return s.DropAllContext(context.Background(), w)
+}
+
+// DropAllContext is like DropAll but takes a context.
+func (s *Service) DropAllContext(ctx context.Context, w io.Writer) error {
+	if err := s.DropAllImagesContext(ctx, w); err != nil {
 		return err
 	}
-	if err := s.DropAllRaws(w); err != nil {
+	if err := s.DropAllRawsContext(ctx, w); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *Service) doGetResources(rtype ResourceType) ([]*Resource, error) {
+// doGetResources lists resources of delivery type dtype, or of all
+// types if empty, and only those whose public id starts with prefix if
+// not empty. Prefix filtering requires the delivery type, upload by
+// default.
+func (s *Service) doGetResources(ctx context.Context, rtype ResourceType, dtype DeliveryType, prefix string) ([]*Resource, error) {
 	qs := url.Values{
 		"max_results": []string{strconv.FormatInt(maxResults, 10)},
 	}
@@ -98,24 +119,43 @@ func (s *Service) doGetResources(rtype ResourceType) ([]*Resource, error) {
 	} else if rtype == VideoType {
 		path = pathListAllVideos
 	}
+	if prefix != "" && dtype == "" {
+		dtype = UploadDelivery
+	}
+	if dtype != "" {
+		path += "/" + string(dtype)
+	}
+	if prefix != "" {
+		qs.Set("prefix", prefix)
+	}
 
 	allres := make([]*Resource, 0)
 	for {
-		resp, err := http.Get(fmt.Sprintf("%s%s?%s", s.adminURI, path, qs.Encode()))
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := s.get(ctx, fmt.Sprintf("%s%s?%s", s.adminURI, path, qs.Encode()))
 		if err != nil {
 			return nil, err
 		}
 
+		if resp.StatusCode != http.StatusOK {
+			err := newAPIError(resp)
+			resp.Body.Close()
+			return nil, err
+		}
 		rs := new(resourceList)
 		dec := json.NewDecoder(resp.Body)
-		if err := dec.Decode(rs); err != nil {
+		err = dec.Decode(rs)
+		resp.Body.Close()
+		if err != nil {
 			return nil, err
 		}
 		for _, res := range rs.Resources {
 			allres = append(allres, res)
 		}
-		if rs.NextCursor > 0 {
-			qs.Set("next_cursor", strconv.FormatInt(rs.NextCursor, 10))
+		if rs.NextCursor != "" {
+			qs.Set("next_cursor", rs.NextCursor)
 		} else {
 			break
 		}
@@ -123,13 +163,22 @@ func (s *Service) doGetResources(rtype ResourceType) ([]*Resource, error) {
 	return allres, nil
 }
 
-func (s *Service) doGetResourceDetails(publicId string) (*ResourceDetails, error) {
-	path := pathListSingleImage
+func (s *Service) doGetResourceDetails(ctx context.Context, publicId string, rtype ResourceType, dtype DeliveryType) (*ResourceDetails, error) {
+	path := pathListAllImages
+	if rtype == RawType {
+		path = pathListAllRaws
+	} else if rtype == VideoType {
+		path = pathListAllVideos
+	}
 
-	resp, err := http.Get(fmt.Sprintf("%s%s%s", s.adminURI, path, publicId))
+	resp, err := s.get(ctx, fmt.Sprintf("%s%s/%s/%s", s.adminURI, path, dtype, publicId))
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
 	details := new(ResourceDetails)
 	dec := json.NewDecoder(resp.Body)
 	if err := dec.Decode(details); err != nil {
@@ -143,10 +192,31 @@ func (s *Service) doGetResourceDetails(publicId string) (*ResourceDetails, error
 // Cloudinary can return a limited set of results. Pagination is supported,
 // so the full set of results is returned.
 func (s *Service) Resources(rtype ResourceType) ([]*Resource, error) {
-	return s.doGetResources(rtype)
+	return s.ResourcesContext(context.Background(), rtype)
+}
+
+// ResourcesContext is like Resources but takes a context.
+func (s *Service) ResourcesContext(ctx context.Context, rtype ResourceType) ([]*Resource, error) {
+	return s.doGetResources(ctx, rtype, "", "")
 }
 
 // GetResourceDetails gets the details of a single resource that is specified by publicId.
 func (s *Service) ResourceDetails(publicId string) (*ResourceDetails, error) {
-	return s.doGetResourceDetails(publicId)
+	return s.ResourceDetailsContext(context.Background(), publicId)
+}
+
+// ResourceDetailsContext is like ResourceDetails but takes a context.
+func (s *Service) ResourceDetailsContext(ctx context.Context, publicId string) (*ResourceDetails, error) {
+	return s.doGetResourceDetails(ctx, publicId, ImageType, UploadDelivery)
+}
+
+// ResourceDetailsType gets the details of a single resource of the given
+// resource and delivery types.
+func (s *Service) ResourceDetailsType(publicId string, rtype ResourceType, dtype DeliveryType) (*ResourceDetails, error) {
+	return s.ResourceDetailsTypeContext(context.Background(), publicId, rtype, dtype)
+}
+
+// ResourceDetailsTypeContext is like ResourceDetailsType but takes a context.
+func (s *Service) ResourceDetailsTypeContext(ctx context.Context, publicId string, rtype ResourceType, dtype DeliveryType) (*ResourceDetails, error) {
+	return s.doGetResourceDetails(ctx, publicId, rtype, dtype)
 }
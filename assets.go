@@ -0,0 +1,124 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// Asset is the delivery information of an uploaded local file.
+type Asset struct {
+	PublicId string `json:"public_id"`
+	Version  uint   `json:"version"`
+	Url      string `json:"url"` // Versioned delivery URL
+}
+
+// AssetManifest maps the paths of the files of an uploaded directory,
+// relative to that directory and slash-separated (e.g. css/default.css),
+// to their asset. Templates can use it to reference local paths while
+// serving cache-busted Cloudinary URLs.
+type AssetManifest map[string]*Asset
+
+// AssetManifest returns the assets of the latest directory upload, done
+// with Upload or UploadDir. Files skipped because unchanged since their
+// last upload are listed too. The manifest is empty in simulation mode.
+func (s *Service) AssetManifest() AssetManifest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := make(AssetManifest, len(s.assets))
+	for k, a := range s.assets {
+		a := *a
+		m[k] = &a
+	}
+	return m
+}
+
+// resetAssets clears the assets recorded during directory uploads.
+func (s *Service) resetAssets() {
+	s.mu.Lock()
+	s.assets = make(AssetManifest)
+	s.mu.Unlock()
+}
+
+// recordAsset adds the file at fullPath, uploaded as res, to the asset
+// manifest when uploading a directory.
+func (s *Service) recordAsset(up *upload, fullPath string, res *UploadResult) {
+	if up.baseDir == "" {
+		return
+	}
+	rel, err := filepath.Rel(up.baseDir, fullPath)
+	if err != nil {
+		return
+	}
+	rtype, ok := resourceTypes[res.ResourceType]
+	if !ok {
+		rtype = up.rtype
+	}
+	source := res.PublicId
+	if res.Format != "" {
+		source += "." + res.Format
+	}
+	opts := []UrlOption{WithVersion(res.Version)}
+	if up.opts != nil && up.opts.Type != "" {
+		opts = append(opts, WithDeliveryType(up.opts.Type))
+	}
+	a := &Asset{
+		PublicId: res.PublicId,
+		Version:  res.Version,
+		Url:      s.Url(source, rtype, opts...),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.assets == nil {
+		s.assets = make(AssetManifest)
+	}
+	s.assets[filepath.ToSlash(rel)] = a
+}
+
+// WriteJSON writes the manifest to w as an indented JSON object, sorted
+// by path.
+func (m AssetManifest) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteGo writes the manifest to w as the source of a Go file of
+// package pkg, declaring an Assets map of the same content.
+func (m AssetManifest) WriteGo(w io.Writer, pkg string) error {
+	paths := make([]string, 0, len(m))
+	for p := range m {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by cloudinary; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	b.WriteString("// Asset is the delivery information of an uploaded local file.\n")
+	b.WriteString("type Asset struct {\nPublicId string\nVersion uint\nUrl string\n}\n\n")
+	b.WriteString("// Assets maps local paths to their asset.\n")
+	b.WriteString("var Assets = map[string]Asset{\n")
+	for _, p := range paths {
+		a := m[p]
+		fmt.Fprintf(&b, "%q: {PublicId: %q, Version: %d, Url: %q},\n", p, a.PublicId, a.Version, a.Url)
+	}
+	b.WriteString("}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
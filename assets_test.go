@@ -0,0 +1,95 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gotsunami/go-cloudinary/cloudinarytest"
+)
+
+func TestAssetManifest(t *testing.T) {
+	srv := cloudinarytest.NewServer("cloud", "key", "secret")
+	defer srv.Close()
+	s, err := Dial(srv.URI(), WithAPIURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, f := range []string{"css/default.css", "img/logo.png"} {
+		p := filepath.Join(dir, f)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := s.Upload(dir, nil, "static", false, ImageType); err != nil {
+		t.Fatal(err)
+	}
+	m := s.AssetManifest()
+	if len(m) != 2 {
+		t.Fatalf("expect 2 assets, got %d", len(m))
+	}
+	a := m["img/logo.png"]
+	if a == nil || a.PublicId != "static/img/logo" {
+		t.Fatalf("wrong asset %+v", a)
+	}
+	ra, _ := srv.Asset("image", "static/img/logo")
+	if a.Version != uint(ra.Version) {
+		t.Errorf("wrong version. Expect %d, got %d", ra.Version, a.Version)
+	}
+	if expect := fmt.Sprintf("https://res.cloudinary.com/cloud/image/upload/v%d/static/img/logo.png", a.Version); a.Url != expect {
+		t.Errorf("wrong url. Expect %s, got %s", expect, a.Url)
+	}
+
+	// Directory uploads start a new manifest
+	report, err := s.UploadDir(filepath.Join(dir, "css"), "", RawType, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Failed()) > 0 {
+		t.Fatal(report.Failed()[0].Err)
+	}
+	m = s.AssetManifest()
+	if len(m) != 1 || m["default.css"] == nil {
+		t.Fatalf("wrong manifest %v", m)
+	}
+
+	var b bytes.Buffer
+	if err := m.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	var back AssetManifest
+	if err := json.Unmarshal(b.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	if *back["default.css"] != *m["default.css"] {
+		t.Errorf("JSON round trip: expect %+v, got %+v", m["default.css"], back["default.css"])
+	}
+
+	b.Reset()
+	if err := m.WriteGo(&b, "assets"); err != nil {
+		t.Fatal(err)
+	}
+	src := b.String()
+	for _, s := range []string{"package assets\n", "var Assets = map[string]Asset{", `"default.css": {PublicId: "default"`} {
+		if !strings.Contains(src, s) {
+			t.Errorf("generated source should contain %q:\n%s", s, src)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const tokenKey = "00112233FF99"
+
+func TestAuthTokenGenerate(t *testing.T) {
+	a := AuthToken{
+		Key:       tokenKey,
+		StartTime: time.Unix(1111111111, 0),
+		Duration:  300 * time.Second,
+		ACL:       "/image/*",
+	}
+	token, err := a.Generate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "__cld_token__=st=1111111111~exp=1111111411~acl=%2fimage%2f*~hmac=1751370bcc6cfe9e03f30dd1a9722ba0f2cdca283fa3e6df3342a00a7528cc51"
+	if token != exp {
+		t.Errorf("wrong token. Expect %s, got %s", exp, token)
+	}
+
+	a = AuthToken{Key: tokenKey, IP: "10.0.0.1", Expiration: time.Unix(1111111411, 0), Name: "tk"}
+	token, err = a.Generate("/image/upload/sample.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = "tk=ip=10.0.0.1~exp=1111111411~hmac="
+	if len(token) != len(exp)+64 || token[:len(exp)] != exp {
+		t.Errorf("wrong token. Expect %s<hmac>, got %s", exp, token)
+	}
+
+	if _, err := (&AuthToken{Key: tokenKey}).Generate("/"); err == nil {
+		t.Error("expect an error without expiration nor duration")
+	}
+	if _, err := (&AuthToken{Key: "xyz", Duration: time.Minute}).Generate("/"); err == nil {
+		t.Error("expect an error with an invalid key")
+	}
+}
+
+func TestUrlAuthToken(t *testing.T) {
+	s, err := Dial("cloudinary://a:b@test123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := AuthToken{Key: tokenKey, StartTime: time.Unix(11111111, 0), Duration: 300 * time.Second}
+	// The token replaces the signature.
+	got := s.Url("v1486020273/sample.jpg", ImageType, WithAuthToken(a), WithSignature())
+	exp := "https://res.cloudinary.com/test123/image/upload/v1486020273/sample.jpg?__cld_token__=st=11111111~exp=11111411~hmac=a704061c971a14c673af553389d44405a7022d935c91a053757964c77b1cbd39"
+	if got != exp {
+		t.Errorf("wrong url. Expect %s, got %s", exp, got)
+	}
+	if _, err := s.SignedUrl("sample.jpg", ImageType, WithAuthToken(AuthToken{Key: tokenKey})); err == nil {
+		t.Error("expect an error with an invalid token")
+	}
+	if got := s.Url("sample.jpg", ImageType, WithAuthToken(AuthToken{Key: tokenKey})); got != "" {
+		t.Errorf("expect no url with an invalid token, got %s", got)
+	}
+	if got, err := s.SignedUrl("v1486020273/sample.jpg", ImageType, WithAuthToken(a)); err != nil || !strings.Contains(got, "__cld_token__=") {
+		t.Errorf("expect a token, got %s, %v", got, err)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package boltstore provides a sync store backed by a local bbolt
+// database file.
+package boltstore
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gotsunami/go-cloudinary"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucket = []byte("sync")
+
+// Store stores sync entries as JSON in the sync bucket of a bbolt
+// database.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the database file at path. Only one process can
+// open a database at a time; Open fails after a second if the file is
+// locked by another process.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database file.
+func (st *Store) Close() error {
+	return st.db.Close()
+}
+
+// Get implements cloudinary.SyncStore.
+func (st *Store) Get(publicId string) (*cloudinary.SyncEntry, error) {
+	var e *cloudinary.SyncEntry
+	err := st.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucket).Get([]byte(publicId))
+		if v == nil {
+			return nil
+		}
+		e = new(cloudinary.SyncEntry)
+		return json.Unmarshal(v, e)
+	})
+	return e, err
+}
+
+// Put implements cloudinary.SyncStore.
+func (st *Store) Put(e *cloudinary.SyncEntry) error {
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return st.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(e.PublicId), v)
+	})
+}
+
+// Delete implements cloudinary.SyncStore.
+func (st *Store) Delete(publicId string) error {
+	return st.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(publicId))
+	})
+}
+
+// List implements cloudinary.SyncStore. Entries are sorted by public id.
+func (st *Store) List() ([]*cloudinary.SyncEntry, error) {
+	var list []*cloudinary.SyncEntry
+	err := st.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+			e := new(cloudinary.SyncEntry)
+			if err := json.Unmarshal(v, e); err != nil {
+				return err
+			}
+			list = append(list, e)
+			return nil
+		})
+	})
+	return list, err
+}
@@ -0,0 +1,65 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package boltstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gotsunami/go-cloudinary"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sync.db")
+	st, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"b", "a"} {
+		if err := st.Put(&cloudinary.SyncEntry{PublicId: id, Version: 1, Checksum: "chk" + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Delete("missing"); err != nil {
+		t.Errorf("deleting a missing entry should not fail, got %v", err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries persist across openings
+	st, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	e, err := st.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == nil || e.Checksum != "chka" || e.Version != 1 {
+		t.Errorf("wrong entry %+v", e)
+	}
+	if e, err := st.Get("b"); e != nil || err != nil {
+		t.Errorf("expect no entry, got %+v, %v", e, err)
+	}
+	list, err := st.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].PublicId != "a" {
+		t.Errorf("wrong entries %v", list)
+	}
+}
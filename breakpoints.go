@@ -0,0 +1,66 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// BreakpointsOptions asks Cloudinary to compute responsive breakpoints
+// of an image at upload time: the widths at which the image file size
+// differs by BytesStep.
+type BreakpointsOptions struct {
+	// CreateDerived stores the scaled images as derived resources.
+	CreateDerived bool `json:"create_derived"`
+	MinWidth      int  `json:"min_width,omitempty"`
+	MaxWidth      int  `json:"max_width,omitempty"`
+	// BytesStep is the minimum file size difference, in bytes, between
+	// two breakpoints.
+	BytesStep int `json:"bytes_step,omitempty"`
+	MaxImages int `json:"max_images,omitempty"`
+	// Transformation applied to the image before scaling, e.g. to crop
+	// it to a given aspect ratio.
+	Transformation *Transformation `json:"-"`
+	// Format of the scaled images, if different.
+	Format string `json:"format,omitempty"`
+}
+
+// MarshalJSON encodes the options as expected by the upload API.
+func (b *BreakpointsOptions) MarshalJSON() ([]byte, error) {
+	type options BreakpointsOptions
+	return json.Marshal(struct {
+		*options
+		Transformation string `json:"transformation,omitempty"`
+	}{(*options)(b), b.Transformation.String()})
+}
+
+// ResponsiveBreakpoints holds the breakpoints computed for one of the
+// requested BreakpointsOptions.
+type ResponsiveBreakpoints struct {
+	Transformation string        `json:"transformation"`
+	Breakpoints    []*Breakpoint `json:"breakpoints"`
+}
+
+// Breakpoint is an image scaled to a breakpoint width.
+type Breakpoint struct {
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Size      int    `json:"bytes"`      // In bytes
+	Url       string `json:"url"`        // Remote url
+	SecureUrl string `json:"secure_url"` // Over https
+}
+
+// Widths returns the breakpoint widths, in ascending order, suitable
+// for Srcset.
+func (r *ResponsiveBreakpoints) Widths() []int {
+	widths := make([]int, len(r.Breakpoints))
+	for i, b := range r.Breakpoints {
+		widths[i] = b.Width
+	}
+	sort.Ints(widths)
+	return widths
+}
@@ -0,0 +1,474 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cloudinarytest provides a fake Cloudinary service for tests.
+//
+// The fake implements the upload, destroy and rename endpoints of the
+// upload API, chunked uploads included, and the resource listing and
+// details endpoints of the admin API. Request signatures and admin
+// credentials are checked. Assets are kept in memory.
+//
+// A service is connected to the fake with
+//
+//	srv := cloudinarytest.NewServer("cloud", "key", "secret")
+//	defer srv.Close()
+//	s, err := cloudinary.Dial(srv.URI(), cloudinary.WithAPIURL(srv.URL))
+package cloudinarytest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Asset is a resource stored by the fake service.
+type Asset struct {
+	PublicId     string
+	ResourceType string // image, video or raw
+	Type         string // Delivery type
+	Format       string
+	Version      int
+	Data         []byte // Content, or file parameter of remote uploads
+	Tags         []string
+	Context      string // As sent, e.g. k=v|k2=v2
+	CreatedAt    time.Time
+}
+
+// Server is a fake Cloudinary service.
+type Server struct {
+	*httptest.Server
+	CloudName string
+	APIKey    string
+	APISecret string
+	// PageSize is the maximum number of resources listed per page, 10 by
+	// default. Smaller than max_results to exercise pagination.
+	PageSize int
+
+	mu      sync.Mutex
+	assets  map[string]*Asset // By resource type, delivery type and public id
+	chunks  map[string]*bytes.Buffer
+	version int
+}
+
+// NewServer starts and returns a fake service for the given
+// credentials. The caller should call Close when finished.
+func NewServer(cloudName, apiKey, apiSecret string) *Server {
+	s := &Server{
+		CloudName: cloudName,
+		APIKey:    apiKey,
+		APISecret: apiSecret,
+		PageSize:  10,
+		assets:    make(map[string]*Asset),
+		chunks:    make(map[string]*bytes.Buffer),
+		version:   1000000000,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// URI returns the cloudinary:// URI holding the credentials of the
+// service, to give to Dial along with the base URL of the server.
+func (s *Server) URI() string {
+	return fmt.Sprintf("cloudinary://%s:%s@%s", s.APIKey, s.APISecret, s.CloudName)
+}
+
+func assetKey(rtype, dtype, publicId string) string {
+	return rtype + "/" + dtype + "/" + publicId
+}
+
+// Asset returns the stored asset of the given resource type (image,
+// video or raw) and public id, with the upload delivery type.
+func (s *Server) Asset(rtype, publicId string) (*Asset, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.assets[assetKey(rtype, "upload", publicId)]
+	return a, ok
+}
+
+// Assets returns all stored assets, sorted by public id.
+func (s *Server) Assets() []*Asset {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedAssets("", "")
+}
+
+// AddAsset stores a, as if uploaded. An empty delivery type is set to
+// upload.
+func (s *Server) AddAsset(a *Asset) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.Type == "" {
+		a.Type = "upload"
+	}
+	if a.Version == 0 {
+		s.version++
+		a.Version = s.version
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	}
+	s.assets[assetKey(a.ResourceType, a.Type, a.PublicId)] = a
+}
+
+// sortedAssets returns assets matching the resource and delivery
+// types, or all if empty.
+func (s *Server) sortedAssets(rtype, dtype string) []*Asset {
+	var list []*Asset
+	for _, a := range s.assets {
+		if (rtype == "" || a.ResourceType == rtype) && (dtype == "" || a.Type == dtype) {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].PublicId < list[j].PublicId })
+	return list
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, format string, args ...interface{}) {
+	writeJSON(w, code, map[string]interface{}{
+		"error": map[string]string{"message": fmt.Sprintf(format, args...)},
+	})
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != s.CloudName {
+		writeError(w, http.StatusNotFound, "Unknown path %s", r.URL.Path)
+		return
+	}
+	if parts[1] == "resources" {
+		if r.Method != "GET" {
+			writeError(w, http.StatusMethodNotAllowed, "Unsupported method %s", r.Method)
+			return
+		}
+		if key, secret, ok := r.BasicAuth(); !ok || key != s.APIKey || secret != s.APISecret {
+			writeError(w, http.StatusUnauthorized, "Invalid credentials")
+			return
+		}
+		s.serveResources(w, r, parts[2:])
+		return
+	}
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Unsupported method %s", r.Method)
+		return
+	}
+	params, file, filename, err := readForm(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	if !s.signed(params) && !(parts[2] == "upload" && params.Get("upload_preset") != "" && params.Get("signature") == "") {
+		writeError(w, http.StatusUnauthorized, "Invalid Signature %s", params.Get("signature"))
+		return
+	}
+	rtype := parts[1]
+	switch parts[2] {
+	case "upload":
+		s.upload(w, r, rtype, params, file, filename)
+	case "destroy":
+		s.destroy(w, rtype, params)
+	case "rename":
+		s.rename(w, rtype, params)
+	default:
+		writeError(w, http.StatusNotFound, "Unknown action %s", parts[2])
+	}
+}
+
+// readForm returns the parameters of a multipart or URL encoded form
+// and the content and name of its file, if any.
+func readForm(r *http.Request) (url.Values, []byte, string, error) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		if err := r.ParseForm(); err != nil {
+			return nil, nil, "", err
+		}
+		return r.PostForm, nil, "", nil
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return nil, nil, "", err
+	}
+	params := url.Values(r.MultipartForm.Value)
+	fh := r.MultipartForm.File["file"]
+	if len(fh) == 0 {
+		return params, nil, "", nil
+	}
+	f, err := fh[0].Open()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	return params, data, fh[0].Filename, err
+}
+
+// Parameters never part of a request signature.
+var unsignedParams = map[string]bool{
+	"file":          true,
+	"api_key":       true,
+	"resource_type": true,
+	"signature":     true,
+}
+
+// signed checks the signature of params, computed with SHA1 or SHA256
+// depending on its length.
+func (s *Server) signed(params url.Values) bool {
+	if params.Get("api_key") != s.APIKey {
+		return false
+	}
+	var keys []string
+	for k, v := range params {
+		if !unsignedParams[k] && strings.Join(v, "") != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + strings.Join(params[k], ",")
+	}
+	sig := params.Get("signature")
+	var h hash.Hash
+	switch len(sig) {
+	case sha1.Size * 2:
+		h = sha1.New()
+	case sha256.Size * 2:
+		h = sha256.New()
+	default:
+		return false
+	}
+	io.WriteString(h, strings.Join(pairs, "&")+s.APISecret)
+	return hex.EncodeToString(h.Sum(nil)) == sig
+}
+
+func randomId() string {
+	b := make([]byte, 10)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// deliveryType returns the delivery type parameter, upload by default.
+func deliveryType(params url.Values) string {
+	if t := params.Get("type"); t != "" {
+		return t
+	}
+	return "upload"
+}
+
+func (s *Server) upload(w http.ResponseWriter, r *http.Request, rtype string, params url.Values, file []byte, filename string) {
+	if file == nil {
+		file = []byte(params.Get("file"))
+		filename = path.Base(params.Get("file"))
+	}
+	if id := r.Header.Get("X-Unique-Upload-Id"); id != "" {
+		var done bool
+		var err error
+		if file, done, err = s.addChunk(id, r.Header.Get("Content-Range"), file); err != nil {
+			writeError(w, http.StatusBadRequest, "%s", err)
+			return
+		}
+		if !done {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"done": false})
+			return
+		}
+	}
+	if len(file) == 0 {
+		writeError(w, http.StatusBadRequest, "Missing required parameter - file")
+		return
+	}
+	publicId := params.Get("public_id")
+	if publicId == "" {
+		publicId = randomId()
+	}
+	if folder := params.Get("folder"); folder != "" {
+		publicId = strings.TrimSuffix(folder, "/") + "/" + publicId
+	}
+	format := strings.TrimPrefix(path.Ext(filename), ".")
+	if rtype == "raw" {
+		format = ""
+	} else if format != "" {
+		publicId = strings.TrimSuffix(publicId, "."+format)
+	}
+	a := &Asset{
+		PublicId:     publicId,
+		ResourceType: rtype,
+		Type:         deliveryType(params),
+		Format:       format,
+		Data:         file,
+		Context:      params.Get("context"),
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+	if tags := params.Get("tags"); tags != "" {
+		a.Tags = strings.Split(tags, ",")
+	}
+
+	s.mu.Lock()
+	key := assetKey(a.ResourceType, a.Type, a.PublicId)
+	if old, ok := s.assets[key]; ok && params.Get("overwrite") == "false" {
+		a = old
+	} else {
+		s.version++
+		a.Version = s.version
+		s.assets[key] = a
+	}
+	res := s.resource(a)
+	s.mu.Unlock()
+
+	res["original_filename"] = strings.TrimSuffix(filename, path.Ext(filename))
+	res["tags"] = a.Tags
+	res["created_at"] = a.CreatedAt.Format(time.RFC3339)
+	writeJSON(w, http.StatusOK, res)
+}
+
+// addChunk appends a chunk of a chunked upload. It returns the whole
+// file once its last chunk is received.
+func (s *Server) addChunk(id, contentRange string, chunk []byte) ([]byte, bool, error) {
+	var start, end, total int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		return nil, false, fmt.Errorf("Invalid Content-Range %q", contentRange)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf, ok := s.chunks[id]
+	if !ok {
+		buf = new(bytes.Buffer)
+		s.chunks[id] = buf
+	}
+	if start != int64(buf.Len()) || end-start+1 != int64(len(chunk)) {
+		return nil, false, fmt.Errorf("Unexpected chunk %s, got %d bytes so far", contentRange, buf.Len())
+	}
+	buf.Write(chunk)
+	if total < 0 {
+		return nil, false, nil
+	}
+	delete(s.chunks, id)
+	return buf.Bytes(), true, nil
+}
+
+func (s *Server) destroy(w http.ResponseWriter, rtype string, params url.Values) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := assetKey(rtype, deliveryType(params), params.Get("public_id"))
+	if _, ok := s.assets[key]; !ok {
+		writeJSON(w, http.StatusOK, map[string]string{"result": "not found"})
+		return
+	}
+	delete(s.assets, key)
+	writeJSON(w, http.StatusOK, map[string]string{"result": "ok"})
+}
+
+func (s *Server) rename(w http.ResponseWriter, rtype string, params url.Values) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dtype := deliveryType(params)
+	from, to := params.Get("from_public_id"), params.Get("to_public_id")
+	a, ok := s.assets[assetKey(rtype, dtype, from)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found - %s", from)
+		return
+	}
+	if _, ok := s.assets[assetKey(rtype, dtype, to)]; ok && params.Get("overwrite") != "true" {
+		writeError(w, http.StatusBadRequest, "to_public_id (%s) already exists", to)
+		return
+	}
+	delete(s.assets, assetKey(rtype, dtype, from))
+	a.PublicId = to
+	s.assets[assetKey(rtype, dtype, to)] = a
+	writeJSON(w, http.StatusOK, s.resource(a))
+}
+
+// resource returns the admin API representation of a.
+func (s *Server) resource(a *Asset) map[string]interface{} {
+	name := a.PublicId
+	if a.Format != "" {
+		name += "." + a.Format
+	}
+	u := fmt.Sprintf("%s/%s/%s/%s/v%d/%s", s.URL, s.CloudName, a.ResourceType, a.Type, a.Version, name)
+	return map[string]interface{}{
+		"public_id":     a.PublicId,
+		"version":       a.Version,
+		"resource_type": a.ResourceType,
+		"type":          a.Type,
+		"format":        a.Format,
+		"bytes":         len(a.Data),
+		"url":           u,
+		"secure_url":    u,
+		"created_at":    a.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// serveResources serves resource listings, /resources/<rtype>[/<type>],
+// and details, /resources/<rtype>/<type>/<public id>.
+func (s *Server) serveResources(w http.ResponseWriter, r *http.Request, parts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rtype, dtype := parts[0], ""
+	if len(parts) > 1 {
+		dtype = parts[1]
+	}
+	if len(parts) > 2 {
+		publicId := strings.Join(parts[2:], "/")
+		a, ok := s.assets[assetKey(rtype, dtype, publicId)]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Resource not found - %s", publicId)
+			return
+		}
+		res := s.resource(a)
+		res["tags"] = a.Tags
+		res["derived"] = []interface{}{}
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
+	list := s.sortedAssets(rtype, dtype)
+	if prefix := r.FormValue("prefix"); prefix != "" {
+		var matching []*Asset
+		for _, a := range list {
+			if strings.HasPrefix(a.PublicId, prefix) {
+				matching = append(matching, a)
+			}
+		}
+		list = matching
+	}
+	size := s.PageSize
+	if n, err := strconv.Atoi(r.FormValue("max_results")); err == nil && n < size {
+		size = n
+	}
+	// The cursor is the public id of the first resource of the page.
+	start := 0
+	if cursor := r.FormValue("next_cursor"); cursor != "" {
+		start = sort.Search(len(list), func(i int) bool { return list[i].PublicId >= cursor })
+	}
+	out := map[string]interface{}{}
+	resources := []interface{}{}
+	for i := start; i < len(list); i++ {
+		if len(resources) == size {
+			out["next_cursor"] = list[i].PublicId
+			break
+		}
+		resources = append(resources, s.resource(list[i]))
+	}
+	out["resources"] = resources
+	writeJSON(w, http.StatusOK, out)
+}
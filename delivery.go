@@ -0,0 +1,183 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"fmt"
+	"hash/crc32"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DeliveryType is the storage and delivery type of a resource. It
+// tells how the resource can be accessed.
+type DeliveryType string
+
+const (
+	// Public resources, the default.
+	UploadDelivery DeliveryType = "upload"
+	// Resources whose original can only be accessed with signed URLs.
+	PrivateDelivery DeliveryType = "private"
+	// Resources only accessible with signed URLs or auth tokens.
+	AuthenticatedDelivery DeliveryType = "authenticated"
+	// Remote resources fetched from their URL and cached.
+	FetchDelivery DeliveryType = "fetch"
+	// Profile pictures of social networks, by user name or id.
+	FacebookDelivery DeliveryType = "facebook"
+	TwitterDelivery  DeliveryType = "twitter"
+	GravatarDelivery DeliveryType = "gravatar"
+	// Video thumbnails, by video id.
+	YoutubeDelivery DeliveryType = "youtube"
+	VimeoDelivery   DeliveryType = "vimeo"
+)
+
+// WithDeliveryType makes Url return the URL of a resource with the
+// given delivery type instead of upload. The public id of a fetched
+// resource is its remote URL.
+func WithDeliveryType(t DeliveryType) UrlOption {
+	return func(o *urlOptions) {
+		o.deliveryType = t
+	}
+}
+
+// smartEscape percent-encodes the characters of a public id, or of the
+// remote URL of a fetched resource, that would break a delivery URL.
+// Slashes and colons are kept, as the official SDKs do.
+func smartEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("_.-/:", c) != -1 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// Shared CDN domain of delivered resources.
+const sharedCDN = "res.cloudinary.com"
+
+// WithSecure makes delivery URLs use HTTPS, the default, or HTTP.
+func WithSecure(secure bool) Option {
+	return func(s *Service) error {
+		s.secure = secure
+		return nil
+	}
+}
+
+// WithCDNSubdomain spreads delivery URLs over the res-1 to res-5
+// subdomains to allow more parallel downloads in browsers. The
+// subdomain of a resource only depends on its public id.
+func WithCDNSubdomain(shard bool) Option {
+	return func(s *Service) error {
+		s.cdnSubdomain = shard
+		return nil
+	}
+}
+
+// WithPrivateCDN makes delivery URLs use the private CDN distribution
+// of the account, <cloud>-res.cloudinary.com.
+func WithPrivateCDN(private bool) Option {
+	return func(s *Service) error {
+		s.privateCDN = private
+		return nil
+	}
+}
+
+// WithSecureDistribution sets the host name of secure delivery URLs,
+// for private CDN distributions with a custom domain.
+func WithSecureDistribution(host string) Option {
+	return func(s *Service) error {
+		s.secureDistribution = host
+		return nil
+	}
+}
+
+// WithCNAME sets a custom domain name for insecure delivery URLs.
+func WithCNAME(host string) Option {
+	return func(s *Service) error {
+		s.cname = host
+		return nil
+	}
+}
+
+// uriOptions returns the delivery options set by the query parameters
+// of a cloudinary:// URI: secure, cdn_subdomain, private_cdn,
+// secure_distribution and cname.
+func uriOptions(q url.Values) ([]Option, error) {
+	var opts []Option
+	for _, b := range []struct {
+		name string
+		opt  func(bool) Option
+	}{
+		{"secure", WithSecure},
+		{"cdn_subdomain", WithCDNSubdomain},
+		{"private_cdn", WithPrivateCDN},
+	} {
+		if v := q.Get(b.name); v != "" {
+			on, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s parameter: %s", b.name, v)
+			}
+			opts = append(opts, b.opt(on))
+		}
+	}
+	if v := q.Get("secure_distribution"); v != "" {
+		opts = append(opts, WithSecureDistribution(v))
+	}
+	if v := q.Get("cname"); v != "" {
+		opts = append(opts, WithCNAME(v))
+	}
+	return opts, nil
+}
+
+// urlPrefix returns the scheme, host and, for shared domains, cloud
+// name part of the delivery URL of publicId.
+func (s *Service) urlPrefix(publicId string) string {
+	if s.resourceURL != "" {
+		return s.resourceURL + "/" + s.cloudName
+	}
+	shard := strconv.Itoa(int(crc32.ChecksumIEEE([]byte(publicId))%5 + 1))
+	shared := !s.privateCDN
+	var prefix string
+	switch {
+	case s.secure:
+		dist := s.secureDistribution
+		if dist == "" {
+			dist = sharedCDN
+			if s.privateCDN {
+				dist = s.cloudName + "-res.cloudinary.com"
+			}
+		}
+		shared = shared || dist == sharedCDN
+		if s.cdnSubdomain && shared {
+			dist = strings.Replace(dist, sharedCDN, "res-"+shard+".cloudinary.com", 1)
+		}
+		prefix = "https://" + dist
+	case s.cname != "":
+		sub := ""
+		if s.cdnSubdomain {
+			sub = "a" + shard + "."
+		}
+		prefix = "http://" + sub + s.cname
+	default:
+		sub := "res"
+		if s.privateCDN {
+			sub = s.cloudName + "-res"
+		}
+		if s.cdnSubdomain {
+			sub += "-" + shard
+		}
+		prefix = "http://" + sub + ".cloudinary.com"
+	}
+	if shared {
+		prefix += "/" + s.cloudName
+	}
+	return prefix
+}
@@ -0,0 +1,89 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"testing"
+)
+
+func TestUrlPrefix(t *testing.T) {
+	tests := []struct {
+		uri string
+		exp string
+	}{
+		{"", "https://res.cloudinary.com/test123/image/upload/test"},
+		{"?secure=false", "http://res.cloudinary.com/test123/image/upload/test"},
+		{"?secure=false&cdn_subdomain=true", "http://res-2.cloudinary.com/test123/image/upload/test"},
+		{"?cdn_subdomain=1", "https://res-2.cloudinary.com/test123/image/upload/test"},
+		{"?secure=false&private_cdn=true", "http://test123-res.cloudinary.com/image/upload/test"},
+		{"?secure=false&private_cdn=true&cdn_subdomain=true", "http://test123-res-2.cloudinary.com/image/upload/test"},
+		{"?private_cdn=true", "https://test123-res.cloudinary.com/image/upload/test"},
+		{"?private_cdn=true&cdn_subdomain=true", "https://test123-res.cloudinary.com/image/upload/test"},
+		{"?private_cdn=true&secure_distribution=media.example.com", "https://media.example.com/image/upload/test"},
+		{"?secure_distribution=media.example.com", "https://media.example.com/test123/image/upload/test"},
+		{"?secure=false&cname=media.example.com", "http://media.example.com/test123/image/upload/test"},
+		{"?secure=false&cname=media.example.com&cdn_subdomain=true", "http://a2.media.example.com/test123/image/upload/test"},
+		{"?cname=media.example.com", "https://res.cloudinary.com/test123/image/upload/test"},
+	}
+	for _, tt := range tests {
+		s, err := Dial("cloudinary://a:b@test123" + tt.uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Url("test", ImageType); got != tt.exp {
+			t.Errorf("%s: wrong url. Expect %s, got %s", tt.uri, tt.exp, got)
+		}
+	}
+	if _, err := Dial("cloudinary://a:b@test123?secure=maybe"); err == nil {
+		t.Error("expect an error with an invalid secure parameter")
+	}
+	s, err := Dial("cloudinary://a:b@test123?secure=false", WithSecure(true), WithResourceURL("http://localhost:8080/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := s.Url("test", ImageType), "http://localhost:8080/test123/image/upload/test"; got != exp {
+		t.Errorf("wrong url. Expect %s, got %s", exp, got)
+	}
+}
+
+func TestUrlDeliveryType(t *testing.T) {
+	s, err := Dial("cloudinary://a:b@test123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		url string
+		exp string
+	}{
+		{s.Url("test", ImageType, WithDeliveryType(PrivateDelivery)), "https://res.cloudinary.com/test123/image/private/test"},
+		{s.Url("test", ImageType, WithDeliveryType(UploadDelivery)), "https://res.cloudinary.com/test123/image/upload/test"},
+		{s.Url("billclinton", ImageType, WithDeliveryType(FacebookDelivery)), "https://res.cloudinary.com/test123/image/facebook/billclinton"},
+		{
+			s.Url("http://example.com/a b?x=1", ImageType, WithDeliveryType(FetchDelivery), WithTransformation(NewTransformation().Width(10))),
+			"https://res.cloudinary.com/test123/image/fetch/w_10/http://example.com/a%20b%3Fx%3D1",
+		},
+	}
+	for _, tt := range tests {
+		if tt.url != tt.exp {
+			t.Errorf("wrong url. Expect %s, got %s", tt.exp, tt.url)
+		}
+	}
+}
+
+func TestUrlEscape(t *testing.T) {
+	s, err := Dial("cloudinary://a:b@test123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "https://res.cloudinary.com/test123/image/upload/my%20folder/%C3%A9%3Fx%23y.png"
+	if u := s.Url("my folder/é?x#y.png", ImageType); u != exp {
+		t.Errorf("wrong url. Expect %s, got %s", exp, u)
+	}
+	// The unescaped public id is signed
+	exp = "https://res.cloudinary.com/test123/image/upload/" + s.urlSignature("my folder/é?x#y.png", shortSignature) + "/my%20folder/%C3%A9%3Fx%23y.png"
+	if u := s.Url("my folder/é?x#y.png", ImageType, WithSignature()); u != exp {
+		t.Errorf("wrong signed url. Expect %s, got %s", exp, u)
+	}
+}
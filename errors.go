@@ -0,0 +1,89 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when the Cloudinary service answers a request
+// with an error status.
+type APIError struct {
+	StatusCode int        // HTTP status code
+	Message    string     // Error message sent by Cloudinary
+	Path       string     // Request path
+	RateLimit  *RateLimit // Admin API rate limit, if sent
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %d %s", e.Path, e.StatusCode, msg)
+}
+
+// newAPIError returns an API error from an error response, reading
+// the message from its body.
+func newAPIError(resp *http.Response) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RateLimit:  parseRateLimit(resp.Header),
+	}
+	if resp.Request != nil {
+		e.Path = resp.Request.URL.Path
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	// JSON error looks like {"error":{"message":"Missing required parameter - public_id"}}
+	var m struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &m); err == nil && m.Error.Message != "" {
+		e.Message = m.Error.Message
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	return e
+}
+
+// hasStatus returns true if err is an API error with one of the
+// status codes.
+func hasStatus(err error, codes ...int) bool {
+	var e *APIError
+	if !errors.As(err, &e) {
+		return false
+	}
+	for _, code := range codes {
+		if e.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound returns true if err reports a missing resource.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsRateLimited returns true if err reports a request rejected because
+// of rate limiting.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, 420, http.StatusTooManyRequests)
+}
+
+// IsUnauthorized returns true if err reports invalid credentials or a
+// request not allowed with the credentials in use.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized, http.StatusForbidden)
+}
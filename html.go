@@ -0,0 +1,282 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ImgOptions holds the attributes of generated <img> tags.
+type ImgOptions struct {
+	// Widths of the scaled versions listed in the srcset attribute.
+	// No srcset attribute is written if empty.
+	Widths []int
+	// Sizes is the sizes attribute, 100vw by default when a srcset is
+	// written.
+	Sizes  string
+	Alt    string
+	Class  string
+	Width  int
+	Height int
+	// Attrs holds additional attributes. Invalid names are skipped.
+	Attrs map[string]string
+}
+
+// PictureSource describes a <source> element of a <picture> tag.
+type PictureSource struct {
+	// Media is the media query selecting the source, e.g.
+	// (min-width: 800px).
+	Media string
+	// Transformation applied to the source, e.g. to crop differently
+	// for small screens.
+	Transformation *Transformation
+	Widths         []int
+	Sizes          string
+}
+
+// VideoOptions holds the attributes of generated <video> tags.
+type VideoOptions struct {
+	// Formats of the <source> elements. Defaults to webm, mp4 and ogv.
+	Formats []string
+	// Poster is the URL of the poster image. Defaults to a frame of the
+	// video in JPEG.
+	Poster   string
+	Controls bool
+	Autoplay bool
+	Loop     bool
+	Muted    bool
+	Width    int
+	Height   int
+	// Attrs holds additional attributes. Invalid names are skipped.
+	Attrs map[string]string
+}
+
+var defaultVideoFormats = []string{"webm", "mp4", "ogv"}
+
+// Srcset returns a srcset attribute value listing the URLs of the
+// resource scaled to the given widths, e.g.
+//
+//	https://res.cloudinary.com/demo/image/upload/c_scale,w_320/sample.jpg 320w, ...
+//
+// The scaling is chained to the transformation given in opts, if any.
+func (s *Service) Srcset(publicId string, rtype ResourceType, widths []int, opts ...UrlOption) string {
+	o := new(urlOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	set := make([]string, len(widths))
+	for i, w := range widths {
+		t := o.transformation.clone().Chain().Crop(CropScale).Width(w)
+		u := s.Url(publicId, rtype, append(opts[:len(opts):len(opts)], WithTransformation(t))...)
+		set[i] = u + " " + strconv.Itoa(w) + "w"
+	}
+	return strings.Join(set, ", ")
+}
+
+// tag builds HTML markup.
+type tag struct {
+	b strings.Builder
+}
+
+// open writes a start tag, without its closing bracket. Attributes are
+// given as name/value pairs, empty values being skipped.
+func (t *tag) open(name string, attrs ...string) {
+	t.b.WriteString("<" + name)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i+1] != "" {
+			fmt.Fprintf(&t.b, ` %s="%s"`, attrs[i], html.EscapeString(attrs[i+1]))
+		}
+	}
+}
+
+// flag writes a boolean attribute if on.
+func (t *tag) flag(name string, on bool) {
+	if on {
+		t.b.WriteString(" " + name)
+	}
+}
+
+// attrName matches valid attribute names.
+var attrName = regexp.MustCompile(`^[a-zA-Z_:][-a-zA-Z0-9_:.]*$`)
+
+// extra writes attrs sorted by name, skipping invalid names which could
+// inject markup.
+func (t *tag) extra(attrs map[string]string) {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		if attrName.MatchString(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&t.b, ` %s="%s"`, k, html.EscapeString(attrs[k]))
+	}
+}
+
+// end closes the start tag.
+func (t *tag) end() {
+	t.b.WriteString(">")
+}
+
+// itoa formats n, zero being the empty string.
+func itoa(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// writeImg writes an <img> tag of the resource.
+func (s *Service) writeImg(t *tag, publicId string, img *ImgOptions, opts []UrlOption) {
+	if img == nil {
+		img = &ImgOptions{}
+	}
+	srcset, sizes := "", ""
+	if len(img.Widths) > 0 {
+		srcset = s.Srcset(publicId, ImageType, img.Widths, opts...)
+		sizes = img.Sizes
+		if sizes == "" {
+			sizes = "100vw"
+		}
+	}
+	t.open("img",
+		"src", s.Url(publicId, ImageType, opts...),
+		"srcset", srcset,
+		"sizes", sizes,
+		"alt", img.Alt,
+		"class", img.Class,
+		"width", itoa(img.Width),
+		"height", itoa(img.Height),
+	)
+	t.extra(img.Attrs)
+	t.end()
+}
+
+// ImgTag returns an <img> tag of the image resource. img can be nil.
+func (s *Service) ImgTag(publicId string, img *ImgOptions, opts ...UrlOption) template.HTML {
+	t := new(tag)
+	s.writeImg(t, publicId, img, opts)
+	return template.HTML(t.b.String())
+}
+
+// PictureTag returns a <picture> tag of the image resource, with a
+// <source> element per source followed by an <img> fallback.
+func (s *Service) PictureTag(publicId string, sources []PictureSource, img *ImgOptions, opts ...UrlOption) template.HTML {
+	t := new(tag)
+	t.open("picture")
+	t.end()
+	for _, src := range sources {
+		sopts := opts
+		if src.Transformation != nil {
+			sopts = append(opts[:len(opts):len(opts)], WithTransformation(src.Transformation))
+		}
+		srcset := s.Url(publicId, ImageType, sopts...)
+		if len(src.Widths) > 0 {
+			srcset = s.Srcset(publicId, ImageType, src.Widths, sopts...)
+		}
+		t.open("source", "media", src.Media, "srcset", srcset, "sizes", src.Sizes)
+		t.end()
+	}
+	s.writeImg(t, publicId, img, opts)
+	t.b.WriteString("</picture>")
+	return template.HTML(t.b.String())
+}
+
+// VideoTag returns a <video> tag of the video resource, with a <source>
+// element per format. video can be nil.
+func (s *Service) VideoTag(publicId string, video *VideoOptions, opts ...UrlOption) template.HTML {
+	if video == nil {
+		video = &VideoOptions{}
+	}
+	formats := video.Formats
+	if len(formats) == 0 {
+		formats = defaultVideoFormats
+	}
+	poster := video.Poster
+	if poster == "" {
+		poster = s.Url(publicId+".jpg", VideoType, opts...)
+	}
+	t := new(tag)
+	t.open("video",
+		"poster", poster,
+		"width", itoa(video.Width),
+		"height", itoa(video.Height),
+	)
+	t.flag("controls", video.Controls)
+	t.flag("autoplay", video.Autoplay)
+	t.flag("loop", video.Loop)
+	t.flag("muted", video.Muted)
+	t.extra(video.Attrs)
+	t.end()
+	for _, f := range formats {
+		mime := "video/" + f
+		if f == "ogv" {
+			mime = "video/ogg"
+		}
+		t.open("source", "src", s.Url(publicId+"."+f, VideoType, opts...), "type", mime)
+		t.end()
+	}
+	t.b.WriteString("</video>")
+	return template.HTML(t.b.String())
+}
+
+// FuncMap returns functions to use the service in html/template
+// templates. Transformations are given in their URL form, e.g.
+// "c_fill,w_200/e_sepia":
+//
+//	{{cldUrl "sample.jpg" "w_200"}}
+//	{{cldSrcset "sample.jpg" "" 320 640}}
+//	{{cldImg "sample.jpg" "w_200" "A sample" 320 640}}
+//	{{cldVideo "dog" ""}}
+func (s *Service) FuncMap() template.FuncMap {
+	withTransformation := func(tr string) ([]UrlOption, error) {
+		if tr == "" {
+			return nil, nil
+		}
+		t, err := ParseTransformation(tr)
+		if err != nil {
+			return nil, err
+		}
+		return []UrlOption{WithTransformation(t)}, nil
+	}
+	return template.FuncMap{
+		"cldUrl": func(publicId, tr string) (string, error) {
+			opts, err := withTransformation(tr)
+			if err != nil {
+				return "", err
+			}
+			return s.Url(publicId, ImageType, opts...), nil
+		},
+		"cldSrcset": func(publicId, tr string, widths ...int) (string, error) {
+			opts, err := withTransformation(tr)
+			if err != nil {
+				return "", err
+			}
+			return s.Srcset(publicId, ImageType, widths, opts...), nil
+		},
+		"cldImg": func(publicId, tr, alt string, widths ...int) (template.HTML, error) {
+			opts, err := withTransformation(tr)
+			if err != nil {
+				return "", err
+			}
+			return s.ImgTag(publicId, &ImgOptions{Alt: alt, Widths: widths}, opts...), nil
+		},
+		"cldVideo": func(publicId, tr string) (template.HTML, error) {
+			opts, err := withTransformation(tr)
+			if err != nil {
+				return "", err
+			}
+			return s.VideoTag(publicId, &VideoOptions{Controls: true}, opts...), nil
+		},
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+)
+
+const testBase = "https://res.cloudinary.com/test123/"
+
+func testService(t *testing.T) *Service {
+	s, err := Dial("cloudinary://a:b@test123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSrcset(t *testing.T) {
+	s := testService(t)
+	got := s.Srcset("sample.jpg", ImageType, []int{320, 640}, WithTransformation(NewTransformation().Effect("sepia")))
+	exp := testBase + "image/upload/e_sepia/c_scale,w_320/sample.jpg 320w, " +
+		testBase + "image/upload/e_sepia/c_scale,w_640/sample.jpg 640w"
+	if got != exp {
+		t.Errorf("wrong srcset. Expect %s, got %s", exp, got)
+	}
+}
+
+func TestImgTag(t *testing.T) {
+	s := testService(t)
+	got := s.ImgTag("sample.jpg", &ImgOptions{
+		Widths: []int{320},
+		Alt:    `A "sample"`,
+		Width:  320,
+		Attrs:  map[string]string{"loading": "lazy", "id": "x", `x><script>alert(1)</script`: "", "data-a b": "c"},
+	})
+	exp := `<img src="` + testBase + `image/upload/sample.jpg" srcset="` + testBase +
+		`image/upload/c_scale,w_320/sample.jpg 320w" sizes="100vw" alt="A &#34;sample&#34;" width="320" id="x" loading="lazy">`
+	if string(got) != exp {
+		t.Errorf("wrong img tag.\nExpect %s\ngot    %s", exp, got)
+	}
+}
+
+func TestPictureTag(t *testing.T) {
+	s := testService(t)
+	got := s.PictureTag("sample.jpg", []PictureSource{
+		{Media: "(max-width: 600px)", Transformation: NewTransformation().Crop(CropFill).Width(600).Height(600)},
+	}, nil)
+	exp := `<picture><source media="(max-width: 600px)" srcset="` + testBase + `image/upload/c_fill,h_600,w_600/sample.jpg">` +
+		`<img src="` + testBase + `image/upload/sample.jpg"></picture>`
+	if string(got) != exp {
+		t.Errorf("wrong picture tag.\nExpect %s\ngot    %s", exp, got)
+	}
+}
+
+func TestVideoTag(t *testing.T) {
+	s := testService(t)
+	got := s.VideoTag("dog", &VideoOptions{Formats: []string{"mp4", "ogv"}, Controls: true, Muted: true})
+	exp := `<video poster="` + testBase + `video/upload/dog.jpg" controls muted>` +
+		`<source src="` + testBase + `video/upload/dog.mp4" type="video/mp4">` +
+		`<source src="` + testBase + `video/upload/dog.ogv" type="video/ogg"></video>`
+	if string(got) != exp {
+		t.Errorf("wrong video tag.\nExpect %s\ngot    %s", exp, got)
+	}
+}
+
+func TestFuncMap(t *testing.T) {
+	s := testService(t)
+	tmpl := template.Must(template.New("").Funcs(s.FuncMap()).Parse(
+		`<a href="{{cldUrl "sample.jpg" "w_200"}}">{{cldImg "sample.jpg" "" "alt"}}</a>`))
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+	exp := `<a href="` + testBase + `image/upload/w_200/sample.jpg"><img src="` + testBase + `image/upload/sample.jpg" alt="alt"></a>`
+	if b.String() != exp {
+		t.Errorf("wrong template output.\nExpect %s\ngot    %s", exp, b.String())
+	}
+	tmpl = template.Must(template.New("").Funcs(s.FuncMap()).Parse(`{{cldUrl "sample.jpg" "folder"}}`))
+	if err := tmpl.Execute(&b, nil); err == nil {
+		t.Error("expect an error with an invalid transformation")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mongosync stores sync entries in a MongoDB collection. It is
+// shared by the mongostore package and the deprecated
+// Service.UseDatabase, built with the mongodb tag, which can't import
+// mongostore.
+package mongosync
+
+import (
+	"errors"
+	"net/url"
+
+	"gopkg.in/mgo.v2"
+)
+
+// Entry has the same fields as cloudinary.SyncEntry, so that pointers
+// can be converted from one type to the other.
+type Entry struct {
+	PublicId     string
+	Version      uint
+	Format       string
+	ResourceType string
+	Size         int
+	Checksum     string
+	Url          string
+}
+
+// Store stores entries in the sync collection of a MongoDB database.
+type Store struct {
+	session *mgo.Session
+	col     *mgo.Collection
+}
+
+// Document of the sync collection.
+type document struct {
+	Id    string `bson:"_id"`
+	Entry `bson:",inline"`
+}
+
+// Dial connects to the database given by a mongodb:// URI.
+func Dial(uri string) (*Store, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "mongodb" {
+		return nil, errors.New("Missing mongodb:// scheme in URI")
+	}
+	if len(u.Path) < 2 {
+		return nil, errors.New("Missing database name in URI")
+	}
+	session, err := mgo.Dial(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		session: session,
+		col:     session.DB(u.Path[1:]).C("sync"),
+	}, nil
+}
+
+// Close closes the connection to the database.
+func (st *Store) Close() {
+	st.session.Close()
+}
+
+// Get returns the entry of publicId, or nil if there is none.
+func (st *Store) Get(publicId string) (*Entry, error) {
+	doc := new(document)
+	err := st.col.FindId(publicId).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Entry, nil
+}
+
+// Put adds or replaces the entry of e.PublicId.
+func (st *Store) Put(e *Entry) error {
+	_, err := st.col.UpsertId(e.PublicId, &document{Id: e.PublicId, Entry: *e})
+	return err
+}
+
+// Delete removes the entry of publicId, if any.
+func (st *Store) Delete(publicId string) error {
+	err := st.col.RemoveId(publicId)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// List returns all entries, sorted by public id.
+func (st *Store) List() ([]*Entry, error) {
+	var docs []*document
+	if err := st.col.Find(nil).Sort("_id").All(&docs); err != nil {
+		return nil, err
+	}
+	list := make([]*Entry, len(docs))
+	for i, doc := range docs {
+		list[i] = &doc.Entry
+	}
+	return list, nil
+}
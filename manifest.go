@@ -0,0 +1,143 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ManifestStore is a SyncStore backed by a JSON manifest file mapping
+// public ids to checksums, versions, formats and URLs. The manifest can
+// be committed along with the assets to upload only changed files
+// without a database.
+//
+// Entries are kept in memory; call Save to write them back.
+type ManifestStore struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]*manifestEntry
+}
+
+// Manifest entry, keyed by public id.
+type manifestEntry struct {
+	Checksum     string `json:"checksum"`
+	Version      uint   `json:"version"`
+	Format       string `json:"format,omitempty"`
+	ResourceType string `json:"resource_type"`
+	Size         int    `json:"bytes"`
+	Url          string `json:"url,omitempty"`
+}
+
+// OpenManifest reads the manifest at path. A missing file is treated as
+// an empty manifest, created by Save.
+func OpenManifest(path string) (*ManifestStore, error) {
+	m := &ManifestStore{path: path, entries: make(map[string]*manifestEntry)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m.entries); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Save writes the manifest atomically: readers of the file see either
+// the previous or the new content, even if the program is interrupted.
+func (m *ManifestStore) Save() error {
+	m.mu.Lock()
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(m.path), filepath.Base(m.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), m.path)
+}
+
+// Get implements SyncStore.
+func (m *ManifestStore) Get(publicId string) (*SyncEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[publicId]
+	if !ok {
+		return nil, nil
+	}
+	return e.entry(publicId), nil
+}
+
+// Put implements SyncStore.
+func (m *ManifestStore) Put(e *SyncEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[e.PublicId] = &manifestEntry{
+		Checksum:     e.Checksum,
+		Version:      e.Version,
+		Format:       e.Format,
+		ResourceType: e.ResourceType,
+		Size:         e.Size,
+		Url:          e.Url,
+	}
+	return nil
+}
+
+// Delete implements SyncStore.
+func (m *ManifestStore) Delete(publicId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, publicId)
+	return nil
+}
+
+// List implements SyncStore. Entries are sorted by public id.
+func (m *ManifestStore) List() ([]*SyncEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]*SyncEntry, 0, len(m.entries))
+	for id, e := range m.entries {
+		list = append(list, e.entry(id))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].PublicId < list[j].PublicId })
+	return list, nil
+}
+
+func (e *manifestEntry) entry(publicId string) *SyncEntry {
+	return &SyncEntry{
+		PublicId:     publicId,
+		Version:      e.Version,
+		Format:       e.Format,
+		ResourceType: e.ResourceType,
+		Size:         e.Size,
+		Checksum:     e.Checksum,
+		Url:          e.Url,
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "assets.json")
+
+	m, err := OpenManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := m.List(); len(list) != 0 {
+		t.Errorf("missing manifest should be empty, got %v", list)
+	}
+	m.Put(&SyncEntry{PublicId: "css/default", Version: 3, ResourceType: "raw", Size: 8, Checksum: "abc", Url: "https://x/css/default"})
+	m.Put(&SyncEntry{PublicId: "img/logo", Version: 1, Format: "png", ResourceType: "image", Checksum: "def"})
+	m.Delete("img/logo")
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != 1 || raw["css/default"]["checksum"] != "abc" || raw["css/default"]["url"] != "https://x/css/default" {
+		t.Errorf("wrong manifest content %s", data)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("expect only the manifest in %s, got %d files", dir, len(files))
+	}
+
+	m, err = OpenManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := m.Get("css/default")
+	if e == nil || e.PublicId != "css/default" || e.Version != 3 || e.Size != 8 || e.Checksum != "abc" {
+		t.Errorf("wrong entry %+v", e)
+	}
+
+	ioutil.WriteFile(path, []byte("{"), 0644)
+	if _, err := OpenManifest(path); err == nil {
+		t.Error("should fail on an invalid manifest")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mongostore provides a sync store backed by a MongoDB
+// collection.
+package mongostore
+
+import (
+	"github.com/gotsunami/go-cloudinary"
+	"github.com/gotsunami/go-cloudinary/internal/mongosync"
+)
+
+// Store stores sync entries in the sync collection of a MongoDB
+// database.
+type Store struct {
+	st *mongosync.Store
+}
+
+// Dial connects to the database given by a mongodb:// URI, e.g.
+//
+//	mongodb://localhost/cloudinary
+func Dial(uri string) (*Store, error) {
+	st, err := mongosync.Dial(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{st}, nil
+}
+
+// Close closes the connection to the database.
+func (st *Store) Close() {
+	st.st.Close()
+}
+
+// Get implements cloudinary.SyncStore.
+func (st *Store) Get(publicId string) (*cloudinary.SyncEntry, error) {
+	e, err := st.st.Get(publicId)
+	return (*cloudinary.SyncEntry)(e), err
+}
+
+// Put implements cloudinary.SyncStore.
+func (st *Store) Put(e *cloudinary.SyncEntry) error {
+	return st.st.Put((*mongosync.Entry)(e))
+}
+
+// Delete implements cloudinary.SyncStore.
+func (st *Store) Delete(publicId string) error {
+	return st.st.Delete(publicId)
+}
+
+// List implements cloudinary.SyncStore.
+func (st *Store) List() ([]*cloudinary.SyncEntry, error) {
+	entries, err := st.st.List()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*cloudinary.SyncEntry, len(entries))
+	for i, e := range entries {
+		list[i] = (*cloudinary.SyncEntry)(e)
+	}
+	return list, nil
+}
@@ -0,0 +1,30 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package mongostore
+
+import (
+	"testing"
+)
+
+func TestDial(t *testing.T) {
+	if _, err := Dial("baduri::"); err == nil {
+		t.Error("should fail on bad uri")
+	}
+	// Bad scheme
+	if _, err := Dial("http://localhost"); err == nil {
+		t.Error("should fail if URL scheme different from mongodb://")
+	}
+	if _, err := Dial("mongodb://localhost"); err == nil {
+		t.Error("should fail without database name")
+	}
+	st, err := Dial("mongodb://localhost/cloudinary")
+	if err != nil {
+		t.Fatal("please ensure you have a running MongoDB server on localhost")
+	}
+	defer st.Close()
+	if st.st == nil {
+		t.Error("store's connection should not be nil")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UploadOptions holds optional upload parameters sent along with
+// the file. All non-zero fields are written to the multipart form
+// and are part of the request signature.
+type UploadOptions struct {
+	// PublicId overrides the public id computed from the file name.
+	// Not suitable for directory uploads.
+	PublicId string
+	// Tags to assign to the uploaded resource.
+	Tags []string
+	// Context holds key/value pairs of custom metadata.
+	Context map[string]string
+	// Folder to upload the resource to. Combined with the public id
+	// by Cloudinary.
+	Folder string
+	// Overwrite controls whether an existing resource with the same
+	// public id is replaced. Cloudinary's default (true) is used when nil.
+	Overwrite *bool
+	// Invalidate requests a CDN invalidation of the previous version
+	// of the resource when overwriting it.
+	Invalidate bool
+	// UploadPreset is the name of an upload preset defined in the
+	// Cloudinary console. Required by unsigned uploads.
+	UploadPreset string
+	// Type is the delivery type of the uploaded resource, upload by
+	// default. Only upload, private and authenticated are allowed.
+	Type DeliveryType
+	// ResponsiveBreakpoints asks for breakpoints computed at upload
+	// time. Results are found in UploadResult.ResponsiveBreakpoints, in
+	// the same order.
+	ResponsiveBreakpoints []*BreakpointsOptions
+}
+
+// contextEscaper escapes the separators used in the context parameter.
+var contextEscaper = strings.NewReplacer("=", `\=`, "|", `\|`)
+
+// values returns the form parameters matching the upload options.
+func (o *UploadOptions) values() url.Values {
+	v := url.Values{}
+	if o == nil {
+		return v
+	}
+	if o.PublicId != "" {
+		v.Set("public_id", o.PublicId)
+	}
+	if len(o.Tags) > 0 {
+		v.Set("tags", strings.Join(o.Tags, ","))
+	}
+	if len(o.Context) > 0 {
+		// Sorted for a stable output
+		keys := make([]string, 0, len(o.Context))
+		for k := range o.Context {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = contextEscaper.Replace(k) + "=" + contextEscaper.Replace(o.Context[k])
+		}
+		v.Set("context", strings.Join(pairs, "|"))
+	}
+	if o.Folder != "" {
+		v.Set("folder", o.Folder)
+	}
+	if o.Overwrite != nil {
+		v.Set("overwrite", strconv.FormatBool(*o.Overwrite))
+	}
+	if o.Invalidate {
+		v.Set("invalidate", "true")
+	}
+	if o.UploadPreset != "" {
+		v.Set("upload_preset", o.UploadPreset)
+	}
+	if o.Type != "" {
+		v.Set("type", string(o.Type))
+	}
+	if len(o.ResponsiveBreakpoints) > 0 {
+		b, _ := json.Marshal(o.ResponsiveBreakpoints)
+		v.Set("responsive_breakpoints", string(b))
+	}
+	return v
+}
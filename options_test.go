@@ -0,0 +1,80 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestUploadOptionsValues(t *testing.T) {
+	var o *UploadOptions
+	if v := o.values(); len(v) != 0 {
+		t.Errorf("nil options should have no values, got %v", v)
+	}
+	no := false
+	o = &UploadOptions{
+		Tags:       []string{"a", "b"},
+		Context:    map[string]string{"k": "v", "alt": "x=y|z"},
+		Folder:     "assets",
+		Overwrite:  &no,
+		Invalidate: true,
+		Type:       PrivateDelivery,
+	}
+	exp := map[string]string{
+		"tags":       "a,b",
+		"context":    `alt=x\=y\|z|k=v`,
+		"folder":     "assets",
+		"overwrite":  "false",
+		"invalidate": "true",
+		"type":       "private",
+	}
+	v := o.values()
+	if len(v) != len(exp) {
+		t.Errorf("wrong number of values. Expect %d, got %d", len(exp), len(v))
+	}
+	for k, e := range exp {
+		if v.Get(k) != e {
+			t.Errorf("wrong %s value. Expect %s, got %s", k, e, v.Get(k))
+		}
+	}
+}
+
+func TestResponsiveBreakpoints(t *testing.T) {
+	o := &UploadOptions{
+		ResponsiveBreakpoints: []*BreakpointsOptions{
+			{CreateDerived: true, MaxWidth: 1000, BytesStep: 20000, Transformation: NewTransformation().Crop(CropFill).Param("ar", "16:9")},
+			{MaxImages: 3, Format: "webp"},
+		},
+	}
+	exp := `[{"create_derived":true,"max_width":1000,"bytes_step":20000,"transformation":"ar_16:9,c_fill"},` +
+		`{"create_derived":false,"max_images":3,"format":"webp"}]`
+	if got := o.values().Get("responsive_breakpoints"); got != exp {
+		t.Errorf("wrong responsive_breakpoints.\nExpect %s\ngot    %s", exp, got)
+	}
+
+	var res UploadResult
+	err := json.Unmarshal([]byte(`{"responsive_breakpoints":[{"transformation":"ar_16:9,c_fill","breakpoints":[
+		{"width":1000,"height":562,"bytes":61250,"url":"http://a","secure_url":"https://a"},
+		{"width":500,"height":281,"bytes":20000,"url":"http://b","secure_url":"https://b"}]}]}`), &res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ResponsiveBreakpoints) != 1 {
+		t.Fatalf("expect 1 breakpoints result, got %d", len(res.ResponsiveBreakpoints))
+	}
+	rb := res.ResponsiveBreakpoints[0]
+	if rb.Breakpoints[1].Size != 20000 || rb.Breakpoints[1].SecureUrl != "https://b" {
+		t.Errorf("wrong breakpoint %+v", rb.Breakpoints[1])
+	}
+	if w := fmt.Sprint(rb.Widths()); w != "[500 1000]" {
+		t.Errorf("wrong widths. Expect [500 1000], got %s", w)
+	}
+	rb.Breakpoints = []*Breakpoint{{Width: 640}, {Width: 1000}, {Width: 320}}
+	if w := fmt.Sprint(rb.Widths()); w != "[320 640 1000]" {
+		t.Errorf("wrong widths. Expect [320 640 1000], got %s", w)
+	}
+}
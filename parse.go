@@ -0,0 +1,121 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParsedUrl holds the parts of a delivery URL.
+type ParsedUrl struct {
+	// CloudName is empty for private CDN and CNAME URLs without the
+	// cloud name in their path.
+	CloudName    string
+	ResourceType ResourceType
+	DeliveryType DeliveryType
+	// Signature is the s--SIGNATURE-- component, if any.
+	Signature      string
+	Transformation *Transformation
+	Version        uint
+	// PublicId is the public id of the resource, without extension
+	// except for raw files. It is the remote URL of fetched resources.
+	PublicId string
+	// Format is the extension of the delivered file, if any.
+	Format string
+}
+
+var (
+	privateCDNHost = regexp.MustCompile(`^(.+)-res(-\d)?\.cloudinary\.com$`)
+	sharedCDNHost  = regexp.MustCompile(`^res(-\d)?\.cloudinary\.com$`)
+	versionPart    = regexp.MustCompile(`^v\d+$`)
+	signaturePart  = regexp.MustCompile(`^s--[A-Za-z0-9_-]+--$`)
+)
+
+var resourceTypes = map[string]ResourceType{
+	imageType: ImageType,
+	videoType: VideoType,
+	rawType:   RawType,
+}
+
+// ParseUrl parses a delivery URL as returned by Url. Transformations
+// are recognized by their parameter names and the public id is
+// unescaped. Query parameters such as auth tokens are ignored.
+func ParseUrl(rawurl string) (*ParsedUrl, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(strings.TrimPrefix(u.EscapedPath(), "/"), "/")
+	p := new(ParsedUrl)
+	if m := privateCDNHost.FindStringSubmatch(u.Hostname()); m != nil {
+		p.CloudName = m[1]
+	}
+	// The cloud name is the first part of URLs on shared domains. It may
+	// be there with custom domains too.
+	if len(parts) > 0 && (sharedCDNHost.MatchString(u.Hostname()) || !isResourceType(parts[0])) {
+		p.CloudName, parts = parts[0], parts[1:]
+	}
+	if len(parts) < 3 || !isResourceType(parts[0]) {
+		return nil, errors.New("not a delivery URL: " + rawurl)
+	}
+	p.ResourceType = resourceTypes[parts[0]]
+	p.DeliveryType = DeliveryType(parts[1])
+	parts = parts[2:]
+
+	if signaturePart.MatchString(parts[0]) && len(parts) > 1 {
+		p.Signature, parts = parts[0], parts[1:]
+	}
+	// Transformation components come before the version, if any.
+	end := 0
+	for i, part := range parts[:len(parts)-1] {
+		if versionPart.MatchString(part) {
+			end = i
+			break
+		}
+		if _, ok := parseComponent(part); !ok {
+			break
+		}
+		end = i + 1
+	}
+	if end > 0 {
+		p.Transformation, err = ParseTransformation(strings.Join(parts[:end], "/"))
+		if err != nil {
+			return nil, err
+		}
+		parts = parts[end:]
+	}
+	if len(parts) > 1 && versionPart.MatchString(parts[0]) {
+		v, err := strconv.ParseUint(parts[0][1:], 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		p.Version = uint(v)
+		parts = parts[1:]
+	}
+
+	id, err := url.PathUnescape(strings.Join(parts, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if p.DeliveryType != FetchDelivery && p.ResourceType != RawType {
+		if ext := path.Ext(id); ext != "" {
+			p.Format = ext[1:]
+			id = strings.TrimSuffix(id, ext)
+		}
+	}
+	p.PublicId = id
+	return p, nil
+}
+
+func isResourceType(s string) bool {
+	_, ok := resourceTypes[s]
+	return ok
+}
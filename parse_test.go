@@ -0,0 +1,109 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseUrl(t *testing.T) {
+	tests := []struct {
+		url string
+		exp ParsedUrl
+		tr  string
+	}{
+		{
+			"https://res.cloudinary.com/test123/image/upload/sample.jpg",
+			ParsedUrl{CloudName: "test123", ResourceType: ImageType, DeliveryType: UploadDelivery, PublicId: "sample", Format: "jpg"},
+			"",
+		},
+		{
+			"http://res-3.cloudinary.com/test123/video/private/s--Ai4Znfl3--/c_crop,h_20,w_10/e_sepia/v1234/folder/my_video.mp4",
+			ParsedUrl{CloudName: "test123", ResourceType: VideoType, DeliveryType: PrivateDelivery, Signature: "s--Ai4Znfl3--", Version: 1234, PublicId: "folder/my_video", Format: "mp4"},
+			"c_crop,h_20,w_10/e_sepia",
+		},
+		{
+			"https://test123-res.cloudinary.com/raw/upload/v1/css/default.css?__cld_token__=exp=1~hmac=0",
+			ParsedUrl{CloudName: "test123", ResourceType: RawType, DeliveryType: UploadDelivery, Version: 1, PublicId: "css/default.css"},
+			"",
+		},
+		{
+			"http://media.example.com/image/authenticated/w_100/folder/w_a",
+			ParsedUrl{ResourceType: ImageType, DeliveryType: AuthenticatedDelivery, PublicId: "folder/w_a"},
+			"w_100",
+		},
+		{
+			"http://media.example.com/test123/image/upload/folder.x/name",
+			ParsedUrl{CloudName: "test123", ResourceType: ImageType, DeliveryType: UploadDelivery, PublicId: "folder.x/name"},
+			"",
+		},
+		{
+			"https://res.cloudinary.com/test123/image/fetch/w_10/http://example.com/a%20b%3Fx%3D1",
+			ParsedUrl{CloudName: "test123", ResourceType: ImageType, DeliveryType: FetchDelivery, PublicId: "http://example.com/a b?x=1"},
+			"w_10",
+		},
+	}
+	for _, tt := range tests {
+		p, err := ParseUrl(tt.url)
+		if err != nil {
+			t.Errorf("%s: %s", tt.url, err)
+			continue
+		}
+		if tr := p.Transformation.String(); tr != tt.tr {
+			t.Errorf("%s: wrong transformation. Expect %q, got %q", tt.url, tt.tr, tr)
+		}
+		p.Transformation = nil
+		if *p != tt.exp {
+			t.Errorf("%s: wrong result. Expect %+v, got %+v", tt.url, tt.exp, *p)
+		}
+	}
+	for _, u := range []string{"https://example.com/", "https://res.cloudinary.com/test123/image/upload", "%"} {
+		if _, err := ParseUrl(u); err == nil {
+			t.Errorf("%s: expect an error", u)
+		}
+	}
+}
+
+func TestParseUrlInverse(t *testing.T) {
+	s, err := Dial("cloudinary://a:b@test123?cdn_subdomain=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := NewTransformation().Width(200).Crop(CropFill).Chain().Overlay("logos/main").Param("e", "blur:300")
+	u := s.Url("my folder/é?x#y.png", VideoType, WithTransformation(tr), WithVersion(1234), WithSignature(), WithDeliveryType(AuthenticatedDelivery))
+	if !strings.HasSuffix(u, "/v1234/my%20folder/%C3%A9%3Fx%23y.png") {
+		t.Fatalf("public id should be escaped, got %s", u)
+	}
+	p, err := ParseUrl(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PublicId != "my folder/é?x#y" || p.Format != "png" || p.ResourceType != VideoType || p.DeliveryType != AuthenticatedDelivery {
+		t.Errorf("wrong parsed url %+v", p)
+	}
+	if p.Transformation.String() != tr.String() {
+		t.Errorf("wrong transformation. Expect %s, got %s", tr, p.Transformation)
+	}
+	back := s.Url(p.PublicId+"."+p.Format, p.ResourceType, WithTransformation(p.Transformation), WithVersion(p.Version), WithSignature(), WithDeliveryType(p.DeliveryType))
+	if back != u {
+		t.Errorf("wrong url from parsed parts. Expect %s, got %s", u, back)
+	}
+}
+
+func TestParseTransformation(t *testing.T) {
+	for _, s := range []string{"w_10", "c_fill,h_10,w_20/l_text:Arial_20:a%2Cb/$v_2"} {
+		tr, err := ParseTransformation(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tr.String() != s {
+			t.Errorf("wrong transformation. Expect %s, got %s", s, tr)
+		}
+	}
+	if _, err := ParseTransformation("folder"); err == nil {
+		t.Error("expect an error")
+	}
+}
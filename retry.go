@@ -0,0 +1,173 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failing requests are sent again. Requests
+// are retried on network errors, 5xx responses and rate limiting (420
+// and 429) responses.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts of a request. Zero or
+	// one disables retries.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry. The delay doubles
+	// after every attempt.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts if non-zero. A rate
+	// limited request asking to wait longer is not retried.
+	MaxBackoff time.Duration
+	// Jitter randomizes delays by up to this fraction (between 0 and 1)
+	// to prevent concurrent clients from retrying at the same time.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is a sensible retry policy, not enabled by default.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  30 * time.Second,
+	Jitter:      0.2,
+}
+
+// WithRetryPolicy makes the service retry failing requests according
+// to p. Requests are not retried by default.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(s *Service) error {
+		s.retry = p
+		return nil
+	}
+}
+
+// RateLimit holds the Admin API usage limits sent along with responses
+// in the X-FeatureRateLimit-* headers.
+type RateLimit struct {
+	Limit     int       // Allowed requests per period
+	Remaining int       // Requests left for the period
+	Reset     time.Time // End of the period
+}
+
+// parseRateLimit returns the rate limit found in h or nil.
+func parseRateLimit(h http.Header) *RateLimit {
+	limit, err := strconv.Atoi(h.Get("X-FeatureRateLimit-Limit"))
+	if err != nil {
+		return nil
+	}
+	rl := &RateLimit{Limit: limit}
+	rl.Remaining, _ = strconv.Atoi(h.Get("X-FeatureRateLimit-Remaining"))
+	rl.Reset, _ = http.ParseTime(h.Get("X-FeatureRateLimit-Reset"))
+	return rl
+}
+
+// RateLimit returns the latest Admin API rate limit reported by the
+// service or nil if unknown.
+func (s *Service) RateLimit() *RateLimit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rateLimit
+}
+
+// Retry modes of a request.
+type retryMode int
+
+const (
+	retryNever    retryMode = iota // Request body can't be sent twice
+	retryRejected                  // Only if rejected because of rate limiting
+	retryAlways                    // Idempotent request
+)
+
+func isRateLimited(code int) bool {
+	return code == 420 || code == http.StatusTooManyRequests
+}
+
+// do sends the request built by newReq, retrying according to the
+// service retry policy and mode. newReq is called for every attempt.
+func (s *Service) do(ctx context.Context, newReq func() (*http.Request, error), mode retryMode) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.client.Do(req)
+		if resp != nil {
+			if rl := parseRateLimit(resp.Header); rl != nil {
+				s.mu.Lock()
+				s.rateLimit = rl
+				s.mu.Unlock()
+			}
+		}
+		if attempt >= s.retry.MaxAttempts || mode == retryNever || ctx.Err() != nil {
+			return resp, err
+		}
+		wait := s.retry.backoff(attempt)
+		switch {
+		case err != nil:
+			if mode != retryAlways {
+				return resp, err
+			}
+		case isRateLimited(resp.StatusCode):
+			if d, ok := retryAfter(resp.Header); ok {
+				if s.retry.MaxBackoff > 0 && d > s.retry.MaxBackoff {
+					return resp, err
+				}
+				wait = d
+			}
+		case resp.StatusCode >= 500 && mode == retryAlways:
+		default:
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if s.verbose {
+			log.Printf("Retrying %s %s in %s\n", req.Method, req.URL.Path, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// backoff returns the delay to wait after the given attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MinBackoff << uint(attempt-1)
+	if d < 0 || (p.MaxBackoff > 0 && d > p.MaxBackoff) {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// retryAfter returns the delay asked by a rate limited response, from
+// the Retry-After header or the end of the rate limit period.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t), true
+		}
+	}
+	if rl := parseRateLimit(h); rl != nil && rl.Remaining == 0 && !rl.Reset.IsZero() {
+		return time.Until(rl.Reset), true
+	}
+	return 0, false
+}
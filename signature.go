@@ -0,0 +1,117 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SignatureAlgorithm is the hash function used to sign API requests.
+type SignatureAlgorithm int
+
+const (
+	SHA1 SignatureAlgorithm = iota
+	SHA256
+)
+
+// Parameters never part of a request signature.
+var unsignedParams = map[string]bool{
+	"file":          true,
+	"api_key":       true,
+	"resource_type": true,
+	"signature":     true,
+}
+
+func (a SignatureAlgorithm) hash() hash.Hash {
+	if a == SHA256 {
+		return sha256.New()
+	}
+	return sha1.New()
+}
+
+// SignParameters returns the hex signature of params using the API secret.
+// The file, api_key and resource_type parameters as well as empty values
+// are ignored. Remaining parameters are sorted by name and serialized as
+// key=value pairs joined with &, multiple values of a parameter being
+// joined with a comma.
+func SignParameters(params url.Values, secret string, algo SignatureAlgorithm) string {
+	keys := make([]string, 0, len(params))
+	for k, v := range params {
+		if unsignedParams[k] || len(strings.Join(v, "")) == 0 {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + strings.Join(params[k], ",")
+	}
+	h := algo.hash()
+	io.WriteString(h, strings.Join(parts, "&")+secret)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// UseSignatureAlgorithm sets the algorithm used to sign API requests.
+// SHA1 is used by default. SHA256 must be enabled on the Cloudinary
+// account before use.
+func (s *Service) UseSignatureAlgorithm(algo SignatureAlgorithm) {
+	s.signatureAlgorithm = algo
+}
+
+// sign adds the signature parameter to params.
+func (s *Service) sign(params url.Values) {
+	params.Set("signature", SignParameters(params, s.apiSecret, s.signatureAlgorithm))
+}
+
+// Kinds of delivery URL signature.
+type urlSignature int
+
+const (
+	noSignature urlSignature = iota
+	shortSignature
+	longSignature
+)
+
+// WithSignature makes Url return a signed URL, required to deliver
+// authenticated resources or to apply transformations on accounts with
+// strict transformations enabled. The signature uses the service's
+// signature algorithm. It is omitted for unsigned services.
+func WithSignature() UrlOption {
+	return func(o *urlOptions) {
+		o.signature = shortSignature
+	}
+}
+
+// WithLongSignature is like WithSignature but uses a 32 characters
+// SHA256 signature. Long signatures must be enabled on the Cloudinary
+// account before use.
+func WithLongSignature() UrlOption {
+	return func(o *urlOptions) {
+		o.signature = longSignature
+	}
+}
+
+// urlSignature returns the s--SIGNATURE-- component signing path, made
+// of the transformation and the public id of a delivery URL.
+func (s *Service) urlSignature(path string, kind urlSignature) string {
+	algo, size := s.signatureAlgorithm, 8
+	if kind == longSignature {
+		algo, size = SHA256, 32
+	}
+	h := algo.hash()
+	io.WriteString(h, path+s.apiSecret)
+	sig := base64.URLEncoding.EncodeToString(h.Sum(nil))
+	return "s--" + sig[:size] + "--"
+}
@@ -0,0 +1,59 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSignParameters(t *testing.T) {
+	params := url.Values{
+		"public_id":     []string{"sample"},
+		"timestamp":     []string{"1315060510"},
+		"tags":          []string{"a", "b"},
+		"file":          []string{"ignored"},
+		"api_key":       []string{"ignored"},
+		"resource_type": []string{"ignored"},
+		"folder":        []string{""},
+	}
+	exp := map[SignatureAlgorithm]string{
+		// Hash of "public_id=sample&tags=a,b&timestamp=1315060510abcd"
+		SHA1:   "f92c0f5257d54acdceb63d8b79e7f2ea9e3583f8",
+		SHA256: "4e1457b1120b2404b24f3bd8575c27e5a599596479525600ba3e8ca2f1f7e1b9",
+	}
+	for algo, e := range exp {
+		if sig := SignParameters(params, "abcd", algo); sig != e {
+			t.Errorf("wrong signature. Expect %s, got %s", e, sig)
+		}
+	}
+}
+
+func TestSignedUrl(t *testing.T) {
+	s, err := Dial("cloudinary://a:b@test123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "https://res.cloudinary.com/test123/image/upload/"
+	tr := NewTransformation().Width(10).Height(20).Crop(CropCrop)
+	tests := []struct {
+		url string
+		exp string
+	}{
+		{s.Url("image.jpg", ImageType, WithTransformation(tr), WithSignature()), "s--Ai4Znfl3--/c_crop,h_20,w_10/image.jpg"},
+		{s.Url("image.jpg", ImageType, WithSignature()), "s----SjmNDA--/image.jpg"},
+		{s.Url("image.jpg", ImageType, WithTransformation(tr), WithVersion(1234), WithSignature()), "s--Ai4Znfl3--/c_crop,h_20,w_10/v1234/image.jpg"},
+		{s.Url("sample.jpg", ImageType, WithLongSignature()), "s--2hbrSMPOjj5BJ4xV7SgFbRDevFaQNUFf--/sample.jpg"},
+	}
+	for _, tt := range tests {
+		if tt.url != base+tt.exp {
+			t.Errorf("wrong signed url. Expect %s, got %s", base+tt.exp, tt.url)
+		}
+	}
+	s.UseSignatureAlgorithm(SHA256)
+	if got, exp := s.Url("sample.jpg", ImageType, WithSignature()), base+"s--2hbrSMPO--/sample.jpg"; got != exp {
+		t.Errorf("wrong SHA256 signed url. Expect %s, got %s", exp, got)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sqlstore provides a sync store backed by a SQL database table,
+// through database/sql.
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gotsunami/go-cloudinary"
+)
+
+// Placeholder is the style of query parameters expected by a driver.
+type Placeholder int
+
+const (
+	Question Placeholder = iota // ?, e.g. MySQL and SQLite
+	Dollar                      // $1, e.g. PostgreSQL
+)
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+const columns = "public_id, version, format, resource_type, size, checksum, url"
+
+// Store stores sync entries in a table, one row per public id.
+type Store struct {
+	db    *sql.DB
+	table string
+	ph    Placeholder
+}
+
+// New returns a store using table, created if it doesn't exist.
+func New(db *sql.DB, table string, ph Placeholder) (*Store, error) {
+	if !tableName.MatchString(table) {
+		return nil, errors.New("invalid table name: " + table)
+	}
+	st := &Store{db: db, table: table, ph: ph}
+	_, err := db.Exec(st.query(`CREATE TABLE IF NOT EXISTS %s (
+		public_id VARCHAR(255) PRIMARY KEY,
+		version BIGINT NOT NULL,
+		format VARCHAR(32) NOT NULL,
+		resource_type VARCHAR(16) NOT NULL,
+		size BIGINT NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		url VARCHAR(1024) NOT NULL
+	)`))
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// query returns q with the table name and placeholders of the store.
+func (st *Store) query(q string) string {
+	q = fmt.Sprintf(q, st.table)
+	if st.ph != Dollar {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range q {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(row scanner) (*cloudinary.SyncEntry, error) {
+	e := new(cloudinary.SyncEntry)
+	err := row.Scan(&e.PublicId, &e.Version, &e.Format, &e.ResourceType, &e.Size, &e.Checksum, &e.Url)
+	return e, err
+}
+
+// Get implements cloudinary.SyncStore.
+func (st *Store) Get(publicId string) (*cloudinary.SyncEntry, error) {
+	e, err := scan(st.db.QueryRow(st.query("SELECT "+columns+" FROM %s WHERE public_id = ?"), publicId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Put implements cloudinary.SyncStore. The row of the public id is
+// replaced in a transaction, to stay portable across databases.
+func (st *Store) Put(e *cloudinary.SyncEntry) error {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(st.query("DELETE FROM %s WHERE public_id = ?"), e.PublicId); err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(st.query("INSERT INTO %s ("+columns+") VALUES (?, ?, ?, ?, ?, ?, ?)"),
+		e.PublicId, e.Version, e.Format, e.ResourceType, e.Size, e.Checksum, e.Url)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete implements cloudinary.SyncStore.
+func (st *Store) Delete(publicId string) error {
+	_, err := st.db.Exec(st.query("DELETE FROM %s WHERE public_id = ?"), publicId)
+	return err
+}
+
+// List implements cloudinary.SyncStore. Entries are sorted by public id.
+func (st *Store) List() ([]*cloudinary.SyncEntry, error) {
+	rows, err := st.db.Query(st.query("SELECT " + columns + " FROM %s ORDER BY public_id"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*cloudinary.SyncEntry
+	for rows.Next() {
+		e, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
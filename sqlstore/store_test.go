@@ -0,0 +1,74 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package sqlstore
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gotsunami/go-cloudinary"
+)
+
+var cols = []string{"public_id", "version", "format", "resource_type", "size", "checksum", "url"}
+
+func TestStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := New(db, "sync; DROP TABLE x", Question); err == nil {
+		t.Error("should fail on invalid table name")
+	}
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS sync ").WillReturnResult(sqlmock.NewResult(0, 0))
+	st, err := New(db, "sync", Dollar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &cloudinary.SyncEntry{PublicId: "a", Version: 2, Format: "png", ResourceType: "image", Size: 10, Checksum: "chk", Url: "https://a"}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sync WHERE public_id = $1")).WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sync (public_id, version, format, resource_type, size, checksum, url) VALUES ($1, $2, $3, $4, $5, $6, $7)")).
+		WithArgs("a", 2, "png", "image", 10, "chk", "https://a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := st.Put(e); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sync WHERE public_id = $1")).WithArgs("a").
+		WillReturnRows(sqlmock.NewRows(cols).AddRow("a", 2, "png", "image", 10, "chk", "https://a"))
+	got, err := st.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || *got != *e {
+		t.Errorf("wrong entry. Expect %+v, got %+v", e, got)
+	}
+	mock.ExpectQuery("FROM sync WHERE").WithArgs("b").WillReturnRows(sqlmock.NewRows(cols))
+	if got, err := st.Get("b"); got != nil || err != nil {
+		t.Errorf("expect no entry, got %+v, %v", got, err)
+	}
+
+	mock.ExpectQuery("FROM sync ORDER BY public_id").
+		WillReturnRows(sqlmock.NewRows(cols).AddRow("a", 2, "png", "image", 10, "chk", "https://a").AddRow("b", 1, "", "raw", 3, "x", ""))
+	list, err := st.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[1].PublicId != "b" || list[1].ResourceType != "raw" {
+		t.Errorf("wrong entries %v", list)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sync WHERE public_id = $1")).WithArgs("a").WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := st.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var errSyncAll = errors.New("sync would delete resources outside of any prepend path, force is required")
+
+// SyncFile is a local file of a synced directory.
+type SyncFile struct {
+	Path     string // Local file path
+	PublicId string // Public id, computed the same way as Upload does
+}
+
+// SyncPlan lists the actions needed to mirror a local directory to the
+// remote resources under a prepend path.
+//
+// Without a sync store, local files can't be compared to their remote
+// resource and all files already uploaded are considered changed.
+type SyncPlan struct {
+	New       []*SyncFile // Local files without remote resource
+	Changed   []*SyncFile // Local files modified since their upload
+	Unchanged []*SyncFile
+	Deleted   []string // Public ids of remote resources without local file
+	Kept      []string // Same as Deleted, but matching the KeepFiles pattern
+}
+
+// WriteTo writes the plan to w, one line per uploaded or deleted
+// resource followed by a summary.
+func (p *SyncPlan) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	for _, f := range p.New {
+		fmt.Fprintf(&b, "+ %s (%s)\n", f.PublicId, f.Path)
+	}
+	for _, f := range p.Changed {
+		fmt.Fprintf(&b, "M %s (%s)\n", f.PublicId, f.Path)
+	}
+	for _, id := range p.Deleted {
+		fmt.Fprintf(&b, "- %s\n", id)
+	}
+	for _, id := range p.Kept {
+		fmt.Fprintf(&b, "K %s (keep)\n", id)
+	}
+	fmt.Fprintf(&b, "%d new, %d changed, %d unchanged, %d to delete, %d kept\n",
+		len(p.New), len(p.Changed), len(p.Unchanged), len(p.Deleted), len(p.Kept))
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// PlanSync compares the files of directory dir with the remote resources
+// whose public id starts with prepend and returns the actions Sync would
+// take. Nothing is uploaded or deleted.
+func (s *Service) PlanSync(dir, prepend string, rtype ResourceType) (*SyncPlan, error) {
+	return s.PlanSyncContext(context.Background(), dir, prepend, rtype)
+}
+
+// PlanSyncContext is like PlanSync but takes a context.
+func (s *Service) PlanSyncContext(ctx context.Context, dir, prepend string, rtype ResourceType) (*SyncPlan, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New("Not a directory: " + dir)
+	}
+	prefix := strings.TrimPrefix(prepend, "/")
+	if prefix != "" {
+		prefix = EnsureTrailingSlash(prefix)
+	}
+	remote, err := s.doGetResources(ctx, rtype, UploadDelivery, prefix)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(remote))
+	for _, r := range remote {
+		exists[r.PublicId] = true
+	}
+
+	plan := new(SyncPlan)
+	local := make(map[string]bool)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f := &SyncFile{Path: path, PublicId: cleanAssetName(path, dir, prepend)}
+		local[f.PublicId] = true
+		if !exists[f.PublicId] {
+			plan.New = append(plan.New, f)
+			return nil
+		}
+		changed, err := s.changedFile(f)
+		if err != nil {
+			return err
+		}
+		if changed {
+			plan.Changed = append(plan.Changed, f)
+		} else {
+			plan.Unchanged = append(plan.Unchanged, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range remote {
+		if local[r.PublicId] {
+			continue
+		}
+		if s.keepFilesPattern != nil && s.keepFilesPattern.MatchString(r.PublicId) {
+			plan.Kept = append(plan.Kept, r.PublicId)
+		} else {
+			plan.Deleted = append(plan.Deleted, r.PublicId)
+		}
+	}
+	sort.Strings(plan.Deleted)
+	sort.Strings(plan.Kept)
+	return plan, nil
+}
+
+// changedFile returns true unless the sync store records the current
+// checksum of f.
+func (s *Service) changedFile(f *SyncFile) (bool, error) {
+	if s.syncStore == nil {
+		return true, nil
+	}
+	e, err := s.syncEntry(f.PublicId, f.Path)
+	if err != nil || e == nil {
+		return true, err
+	}
+	chk, err := fileChecksum(f.Path)
+	if err != nil {
+		return false, err
+	}
+	return chk != e.Checksum, nil
+}
+
+// Sync mirrors directory dir to the remote resources whose public id
+// starts with prepend: new and changed files are uploaded, and remote
+// uploaded resources without local file are deleted unless they match
+// the KeepFiles pattern.
+//
+// With an empty prepend, all uploaded resources of type rtype are
+// considered. Sync then fails before acting if some would be deleted,
+// unless force is true.
+//
+// The plan is written to w, if not nil, before acting. In simulation
+// mode, Sync stops after writing the plan. The returned plan lists the
+// actions taken.
+func (s *Service) Sync(dir, prepend string, rtype ResourceType, force bool, w io.Writer) (*SyncPlan, error) {
+	return s.SyncContext(context.Background(), dir, prepend, rtype, force, w)
+}
+
+// SyncContext is like Sync but takes a context.
+func (s *Service) SyncContext(ctx context.Context, dir, prepend string, rtype ResourceType, force bool, w io.Writer) (*SyncPlan, error) {
+	plan, err := s.PlanSyncContext(ctx, dir, prepend, rtype)
+	if err != nil {
+		return nil, err
+	}
+	if w != nil {
+		if _, err := plan.WriteTo(w); err != nil {
+			return nil, err
+		}
+	}
+	if strings.Trim(prepend, "/") == "" && len(plan.Deleted) > 0 && !force {
+		return plan, errSyncAll
+	}
+	if s.simulate {
+		return plan, nil
+	}
+
+	up := &upload{rtype: rtype, baseDir: dir, prepend: prepend}
+	s.resetAssets()
+	// Resources deleted without Delete, e.g. from the dashboard, keep their
+	// sync entry, which would prevent uploading them again.
+	if s.syncStore != nil {
+		for _, f := range plan.New {
+			if err := s.syncStore.Delete(f.PublicId); err != nil {
+				return plan, err
+			}
+			if err := s.syncStore.Delete(f.PublicId + filepath.Ext(f.Path)); err != nil {
+				return plan, err
+			}
+		}
+	}
+	for _, f := range append(plan.New[:len(plan.New):len(plan.New)], plan.Changed...) {
+		if err := ctx.Err(); err != nil {
+			return plan, err
+		}
+		if _, err := s.uploadFile(ctx, up, f.Path, nil, false, nil); err != nil {
+			return plan, err
+		}
+	}
+	for _, id := range plan.Deleted {
+		res, err := s.destroy(ctx, id, rtype, UploadDelivery)
+		if err != nil {
+			return plan, err
+		}
+		if w != nil {
+			fmt.Fprintf(w, "Deleting %s ... %s\n", id, res)
+		}
+	}
+	return plan, nil
+}
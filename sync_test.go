@@ -0,0 +1,243 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gotsunami/go-cloudinary/cloudinarytest"
+)
+
+func TestSync(t *testing.T) {
+	srv := cloudinarytest.NewServer("cloud", "key", "secret")
+	defer srv.Close()
+	s, err := Dial(srv.URI(), WithAPIURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.UseSyncStore(NewMemoryStore())
+
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		p := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("css/a.css", "a")
+	write("css/b.css", "b")
+	write("js/old.js", "old")
+	write("js/keep.js", "keep")
+	if _, err := s.Upload(dir, nil, "static", false, RawType); err != nil {
+		t.Fatal(err)
+	}
+	// Outside of the synced prefix
+	srv.AddAsset(&cloudinarytest.Asset{PublicId: "other/x", ResourceType: "raw", Type: "upload"})
+
+	write("css/b.css", "b2")
+	write("css/c.css", "c")
+	os.Remove(filepath.Join(dir, "js/old.js"))
+	os.Remove(filepath.Join(dir, "js/keep.js"))
+	if err := s.KeepFiles("keep"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Simulate(true)
+	var b bytes.Buffer
+	plan, err := s.Sync(dir, "static", RawType, false, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(files []*SyncFile) string {
+		var list []string
+		for _, f := range files {
+			list = append(list, f.PublicId)
+		}
+		return strings.Join(list, ",")
+	}
+	tests := []struct {
+		name, got, expect string
+	}{
+		{"new", ids(plan.New), "static/css/c"},
+		{"changed", ids(plan.Changed), "static/css/b"},
+		{"unchanged", ids(plan.Unchanged), "static/css/a"},
+		{"deleted", strings.Join(plan.Deleted, ","), "static/js/old"},
+		{"kept", strings.Join(plan.Kept, ","), "static/js/keep"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expect {
+			t.Errorf("%s files: expect %q, got %q", tt.name, tt.expect, tt.got)
+		}
+	}
+	if !strings.HasSuffix(b.String(), "1 new, 1 changed, 1 unchanged, 1 to delete, 1 kept\n") {
+		t.Errorf("wrong plan output:\n%s", b.String())
+	}
+	if _, ok := srv.Asset("raw", "static/js/old"); !ok {
+		t.Fatal("nothing should be deleted in simulation mode")
+	}
+
+	s.Simulate(false)
+	if _, err := s.Sync(dir, "static", RawType, false, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"static/css/a", "static/css/b", "static/css/c", "static/js/keep", "other/x"} {
+		if _, ok := srv.Asset("raw", id); !ok {
+			t.Errorf("missing %s", id)
+		}
+	}
+	if a, _ := srv.Asset("raw", "static/css/b"); a == nil || string(a.Data) != "b2" {
+		t.Errorf("changed file should be uploaded again")
+	}
+	if _, ok := srv.Asset("raw", "static/js/old"); ok {
+		t.Error("vanished file should be deleted")
+	}
+}
+
+func TestSyncStaleEntry(t *testing.T) {
+	srv := cloudinarytest.NewServer("cloud", "key", "secret")
+	defer srv.Close()
+	s, err := Dial(srv.URI(), WithAPIURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := NewMemoryStore()
+	s.UseSyncStore(st)
+
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.css"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Sync(dir, "static", RawType, false, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	// Deleted remotely without Delete: the sync entry remains
+	srv.Close()
+	srv = cloudinarytest.NewServer("cloud", "key", "secret")
+	defer srv.Close()
+	if s, err = Dial(srv.URI(), WithAPIURL(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+	s.UseSyncStore(st)
+	if e, _ := st.Get("static/a"); e == nil {
+		t.Fatal("missing sync entry")
+	}
+
+	var b bytes.Buffer
+	plan, err := s.Sync(dir, "static", RawType, false, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.New) != 1 {
+		t.Fatalf("expect a new file, got plan:\n%s", b.String())
+	}
+	if _, ok := srv.Asset("raw", "static/a"); !ok {
+		t.Error("file should be uploaded again")
+	}
+}
+
+func TestSyncOutput(t *testing.T) {
+	srv := cloudinarytest.NewServer("cloud", "key", "secret")
+	defer srv.Close()
+	s, err := Dial(srv.URI(), WithAPIURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.AddAsset(&cloudinarytest.Asset{PublicId: "static/gone", ResourceType: "raw"})
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var b bytes.Buffer
+	if _, err := s.Sync(dir, "static", RawType, false, &b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), "Deleting static/gone ... ok\n") {
+		t.Errorf("wrong output:\n%s", b.String())
+	}
+}
+
+func TestSyncWithoutPrepend(t *testing.T) {
+	srv := cloudinarytest.NewServer("cloud", "key", "secret")
+	defer srv.Close()
+	s, err := Dial(srv.URI(), WithAPIURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.AddAsset(&cloudinarytest.Asset{PublicId: "gone", ResourceType: "raw"})
+	srv.AddAsset(&cloudinarytest.Asset{PublicId: "private", ResourceType: "raw", Type: "private"})
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plan, err := s.Sync(dir, "", RawType, false, ioutil.Discard)
+	if err == nil {
+		t.Fatal("should fail to delete without prepend path")
+	}
+	if strings.Join(plan.Deleted, ",") != "gone" {
+		t.Errorf("only uploaded resources should be deleted, got %v", plan.Deleted)
+	}
+	if _, ok := srv.Asset("raw", "gone"); !ok {
+		t.Fatal("nothing should be deleted without force")
+	}
+	if _, err := s.Sync(dir, "", RawType, true, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Asset("raw", "gone"); ok {
+		t.Error("vanished file should be deleted with force")
+	}
+}
+
+func TestSyncEntryWithExtension(t *testing.T) {
+	srv := cloudinarytest.NewServer("cloud", "key", "secret")
+	defer srv.Close()
+	s, err := Dial(srv.URI(), WithAPIURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "a.css")
+	if err := ioutil.WriteFile(p, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chk, err := fileChecksum(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Uploads skip files recorded with their extension, so must plans
+	st := NewMemoryStore()
+	st.Put(&SyncEntry{PublicId: "static/a.css", Checksum: chk})
+	s.UseSyncStore(st)
+	srv.AddAsset(&cloudinarytest.Asset{PublicId: "static/a", ResourceType: "raw"})
+
+	plan, err := s.PlanSync(dir, "static", RawType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Unchanged) != 1 {
+		t.Errorf("expect an unchanged file, got %+v", plan)
+	}
+}
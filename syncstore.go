@@ -0,0 +1,124 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// SyncEntry records a file uploaded to Cloudinary along with the
+// checksum of its content, to prevent uploading it again while
+// unchanged.
+type SyncEntry struct {
+	PublicId     string `json:"public_id"`
+	Version      uint   `json:"version"`
+	Format       string `json:"format"`
+	ResourceType string `json:"resource_type"` // image, video or raw
+	Size         int    `json:"bytes"`         // In bytes
+	Checksum     string `json:"checksum"`      // SHA1 checksum of the file
+	Url          string `json:"url"`           // Secure url, if known
+}
+
+// result returns the upload result matching a sync entry.
+func (e *SyncEntry) result() *UploadResult {
+	return &UploadResult{
+		PublicId:     e.PublicId,
+		Version:      e.Version,
+		Format:       e.Format,
+		ResourceType: e.ResourceType,
+		Size:         e.Size,
+		SecureUrl:    e.Url,
+	}
+}
+
+// SyncStore persists sync entries by public id. Implementations must be
+// safe for concurrent use.
+//
+// ManifestStore keeps entries in a JSON file. The boltstore, sqlstore
+// and mongostore subpackages provide stores backed by a local database
+// file, a SQL database and MongoDB.
+type SyncStore interface {
+	// Get returns the entry of publicId, or nil if there is none.
+	Get(publicId string) (*SyncEntry, error)
+	// Put adds or replaces the entry of e.PublicId.
+	Put(e *SyncEntry) error
+	// Delete removes the entry of publicId, if any.
+	Delete(publicId string) error
+	// List returns all entries.
+	List() ([]*SyncEntry, error)
+}
+
+// UseSyncStore makes the service record uploaded files in st, along with
+// their checksum, and skip uploading files unchanged since. A nil store
+// disables checksum checks.
+func (s *Service) UseSyncStore(st SyncStore) {
+	s.syncStore = st
+}
+
+// syncEntry returns the sync entry of the local file fullPath, uploaded
+// as publicId, or nil if there is none. The entry may be recorded with
+// the file extension, as public ids of raw files have one.
+func (s *Service) syncEntry(publicId, fullPath string) (*SyncEntry, error) {
+	e, err := s.syncStore.Get(publicId)
+	if err == nil && e == nil {
+		e, err = s.syncStore.Get(publicId + filepath.Ext(fullPath))
+	}
+	return e, err
+}
+
+// MemoryStore is a SyncStore keeping entries in memory, for the life of
+// the program.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]SyncEntry
+}
+
+// NewMemoryStore returns an empty memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]SyncEntry)}
+}
+
+// Get implements SyncStore.
+func (m *MemoryStore) Get(publicId string) (*SyncEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[publicId]
+	if !ok {
+		return nil, nil
+	}
+	return &e, nil
+}
+
+// Put implements SyncStore.
+func (m *MemoryStore) Put(e *SyncEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[e.PublicId] = *e
+	return nil
+}
+
+// Delete implements SyncStore.
+func (m *MemoryStore) Delete(publicId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, publicId)
+	return nil
+}
+
+// List implements SyncStore. Entries are sorted by public id.
+func (m *MemoryStore) List() ([]*SyncEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]*SyncEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		e := e
+		list = append(list, &e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].PublicId < list[j].PublicId })
+	return list, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gotsunami/go-cloudinary/cloudinarytest"
+)
+
+func TestSyncStore(t *testing.T) {
+	srv := cloudinarytest.NewServer("cloud", "key", "secret")
+	defer srv.Close()
+	s, err := Dial(srv.URI(), WithAPIURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := NewMemoryStore()
+	s.UseSyncStore(st)
+
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.png")
+	upload := func(content string) *UploadResult {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		res, err := s.UploadResource(path, nil, "", false, ImageType, &UploadOptions{PublicId: "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	first := upload("v1")
+	if e, _ := st.Get("a"); e == nil || e.Version != first.Version || e.Checksum == "" {
+		t.Fatalf("wrong sync entry %+v", e)
+	}
+	if res := upload("v1"); res.Version != first.Version {
+		t.Errorf("unchanged file should not be uploaded again")
+	}
+	if res := upload("v2"); res.Version == first.Version {
+		t.Errorf("changed file should be uploaded again")
+	}
+	if a, _ := srv.Asset("image", "a"); string(a.Data) != "v2" {
+		t.Errorf("wrong uploaded content %q", a.Data)
+	}
+
+	if err := s.Delete("a", "", ImageType); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := st.List(); len(list) != 0 {
+		t.Errorf("deleted resource should have no sync entry, got %v", list)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CropMode tells how to resize an image to the requested dimensions.
+type CropMode string
+
+const (
+	CropScale CropMode = "scale"
+	CropFit   CropMode = "fit"
+	CropLimit CropMode = "limit"
+	CropMfit  CropMode = "mfit"
+	CropFill  CropMode = "fill"
+	CropLfill CropMode = "lfill"
+	CropPad   CropMode = "pad"
+	CropLpad  CropMode = "lpad"
+	CropMpad  CropMode = "mpad"
+	CropCrop  CropMode = "crop"
+	CropThumb CropMode = "thumb"
+)
+
+// Gravity tells which part of an image to keep when cropping.
+type Gravity string
+
+const (
+	GravityCenter    Gravity = "center"
+	GravityNorth     Gravity = "north"
+	GravityNorthEast Gravity = "north_east"
+	GravityNorthWest Gravity = "north_west"
+	GravitySouth     Gravity = "south"
+	GravitySouthEast Gravity = "south_east"
+	GravitySouthWest Gravity = "south_west"
+	GravityEast      Gravity = "east"
+	GravityWest      Gravity = "west"
+	GravityFace      Gravity = "face"
+	GravityFaces     Gravity = "faces"
+	GravityAuto      Gravity = "auto"
+)
+
+// Transformation describes how Cloudinary should transform a resource
+// before delivering it. A transformation is made of chained components,
+// each one being applied to the result of the previous one. Methods set
+// parameters of the current component and can be chained, e.g.
+//
+//	t := NewTransformation().Width(200).Height(100).Crop(CropFill).
+//		Chain().Effect("sepia")
+//
+// is rendered as
+//
+//	c_fill,h_100,w_200/e_sepia
+type Transformation struct {
+	components []map[string]string
+}
+
+// Escapes characters that have a meaning in URL transformations.
+var transformationEscaper = strings.NewReplacer(",", "%2C", "/", "%2F", " ", "%20")
+
+// NewTransformation returns an empty transformation.
+func NewTransformation() *Transformation {
+	return &Transformation{components: []map[string]string{{}}}
+}
+
+// Param sets parameter key (short name, e.g. w for width) of the
+// current component to value. Useful for parameters without a
+// dedicated method.
+func (t *Transformation) Param(key, value string) *Transformation {
+	return t.set(key, transformationEscaper.Replace(value))
+}
+
+// set sets parameter key of the current component to an escaped value.
+func (t *Transformation) set(key, value string) *Transformation {
+	if len(t.components) == 0 {
+		t.components = append(t.components, map[string]string{})
+	}
+	t.components[len(t.components)-1][key] = value
+	return t
+}
+
+// Width sets the width in pixels.
+func (t *Transformation) Width(w int) *Transformation {
+	return t.Param("w", strconv.Itoa(w))
+}
+
+// Height sets the height in pixels.
+func (t *Transformation) Height(h int) *Transformation {
+	return t.Param("h", strconv.Itoa(h))
+}
+
+// Crop sets the crop mode.
+func (t *Transformation) Crop(c CropMode) *Transformation {
+	return t.Param("c", string(c))
+}
+
+// Gravity sets the gravity used when cropping.
+func (t *Transformation) Gravity(g Gravity) *Transformation {
+	return t.Param("g", string(g))
+}
+
+// Quality sets the compression quality, e.g. 80 or auto.
+func (t *Transformation) Quality(q string) *Transformation {
+	return t.Param("q", q)
+}
+
+// Format sets the delivery format, e.g. webp or auto.
+func (t *Transformation) Format(f string) *Transformation {
+	return t.Param("f", f)
+}
+
+// Effect applies an effect, with optional argument, e.g. sepia or
+// blur:300.
+func (t *Transformation) Effect(e string) *Transformation {
+	return t.Param("e", e)
+}
+
+// Overlay adds the image with the given public id on top of the
+// resource. Folders of the public id are handled.
+func (t *Transformation) Overlay(publicId string) *Transformation {
+	return t.Param("l", strings.Replace(publicId, "/", ":", -1))
+}
+
+// Radius rounds corners by the given number of pixels, or max to get
+// a circle or ellipse.
+func (t *Transformation) Radius(r string) *Transformation {
+	return t.Param("r", r)
+}
+
+// Named applies named transformations defined in the Cloudinary
+// console.
+func (t *Transformation) Named(names ...string) *Transformation {
+	return t.Param("t", strings.Join(names, "."))
+}
+
+// Chain starts a new component, applied to the result of the previous
+// ones.
+func (t *Transformation) Chain() *Transformation {
+	t.components = append(t.components, map[string]string{})
+	return t
+}
+
+// Transformation parameter names.
+var transformationParams = map[string]bool{
+	"a": true, "ac": true, "af": true, "ar": true, "b": true, "bo": true,
+	"br": true, "c": true, "co": true, "cs": true, "d": true, "dl": true,
+	"dn": true, "dpr": true, "du": true, "e": true, "eo": true, "f": true,
+	"fl": true, "fn": true, "fps": true, "g": true, "h": true, "ki": true,
+	"l": true, "o": true, "p": true, "pg": true, "q": true, "r": true,
+	"so": true, "sp": true, "t": true, "u": true, "vc": true, "vs": true,
+	"w": true, "x": true, "y": true, "z": true,
+}
+
+// parseComponent parses a transformation component of a delivery URL.
+// It returns false if comp doesn't look like a component.
+func parseComponent(comp string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for _, p := range strings.Split(comp, ",") {
+		i := strings.Index(p, "_")
+		if i <= 0 {
+			return nil, false
+		}
+		key := p[:i]
+		if !transformationParams[key] && key[0] != '$' {
+			return nil, false
+		}
+		params[key] = p[i+1:]
+	}
+	return params, true
+}
+
+// ParseTransformation parses a transformation as found in delivery
+// URLs, e.g. c_fill,h_100,w_200/e_sepia.
+func ParseTransformation(s string) (*Transformation, error) {
+	t := &Transformation{}
+	for _, comp := range strings.Split(s, "/") {
+		params, ok := parseComponent(comp)
+		if !ok {
+			return nil, fmt.Errorf("invalid transformation component: %s", comp)
+		}
+		t.components = append(t.components, params)
+	}
+	return t, nil
+}
+
+// clone returns a deep copy of t.
+func (t *Transformation) clone() *Transformation {
+	c := &Transformation{}
+	if t == nil {
+		return c
+	}
+	for _, comp := range t.components {
+		m := make(map[string]string, len(comp))
+		for k, v := range comp {
+			m[k] = v
+		}
+		c.components = append(c.components, m)
+	}
+	return c
+}
+
+// String returns the transformation as used in delivery URLs.
+// Parameters of a component are sorted by name.
+func (t *Transformation) String() string {
+	if t == nil {
+		return ""
+	}
+	comps := make([]string, 0, len(t.components))
+	for _, c := range t.components {
+		if len(c) == 0 {
+			continue
+		}
+		params := make([]string, 0, len(c))
+		for k, v := range c {
+			params = append(params, k+"_"+v)
+		}
+		sort.Strings(params)
+		comps = append(comps, strings.Join(params, ","))
+	}
+	return strings.Join(comps, "/")
+}
+
+// WithTransformation makes Url return the URL of the resource
+// transformed by t.
+func WithTransformation(t *Transformation) UrlOption {
+	return func(o *urlOptions) {
+		o.transformation = t
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileUpload reports the upload of a single file of a directory.
+type FileUpload struct {
+	Path   string        // Local file path
+	Result *UploadResult // Nil if the file was not uploaded
+	Err    error         // Upload error, if any
+}
+
+// UploadReport lists the uploads of all files of a directory, in
+// lexical order.
+type UploadReport struct {
+	Files []*FileUpload
+}
+
+// Failed returns the file uploads that ended with an error.
+func (r *UploadReport) Failed() []*FileUpload {
+	failed := make([]*FileUpload, 0)
+	for _, f := range r.Files {
+		if f.Err != nil {
+			failed = append(failed, f)
+		}
+	}
+	return failed
+}
+
+// UploadDir recursively uploads all files of directory dir using up to
+// workers concurrent uploads. Public ids are computed the same way as
+// Upload does.
+//
+// Unlike Upload, a failing file upload does not stop the process: all
+// files are handled and the outcome of every upload is available in the
+// returned report. An error is only returned if the directory can't be
+// walked.
+func (s *Service) UploadDir(dir, prepend string, rtype ResourceType, workers int, opts *UploadOptions) (*UploadReport, error) {
+	return s.UploadDirContext(context.Background(), dir, prepend, rtype, workers, opts)
+}
+
+// UploadDirContext is like UploadDir but takes a context.
+func (s *Service) UploadDirContext(ctx context.Context, dir, prepend string, rtype ResourceType, workers int, opts *UploadOptions) (*UploadReport, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New("Not a directory: " + dir)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	up := &upload{rtype: rtype, opts: opts, baseDir: dir, prepend: prepend}
+	s.resetAssets()
+
+	report := new(UploadReport)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			report.Files = append(report.Files, &FileUpload{Path: path})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make(chan *FileUpload)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				if f.Err = ctx.Err(); f.Err == nil {
+					f.Result, f.Err = s.uploadFile(ctx, up, f.Path, nil, false, nil)
+				}
+			}
+		}()
+	}
+	for _, f := range report.Files {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+	return report, nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+const (
+	// Files bigger than this are uploaded in chunks by default.
+	defaultLargeThreshold = 100 * 1024 * 1024
+	defaultChunkSize      = 20 * 1024 * 1024
+)
+
+// chunkedUpload holds the state of a chunked upload.
+type chunkedUpload struct {
+	id     string // Sent as X-Unique-Upload-Id header
+	offset int64  // Number of bytes acknowledged by the service
+}
+
+func newChunkedUpload() (*chunkedUpload, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &chunkedUpload{id: fmt.Sprintf("%x", b)}, nil
+}
+
+// LargeUploadError is returned when a chunked upload fails part way.
+// It can be passed to ResumeLarge to resume the upload from the last
+// chunk acknowledged by the service.
+type LargeUploadError struct {
+	UploadId string // Unique id of the chunked upload
+	Offset   int64  // Number of bytes acknowledged by the service
+	Err      error
+}
+
+func (e *LargeUploadError) Error() string {
+	return fmt.Sprintf("chunked upload %s failed at byte %d: %s", e.UploadId, e.Offset, e.Err.Error())
+}
+
+func (e *LargeUploadError) Unwrap() error {
+	return e.Err
+}
+
+// ChunkSize sets the size in bytes of the chunks sent by chunked uploads.
+// Cloudinary requires chunks (but the last one) to be at least 5MB.
+// Defaults to 20MB.
+func (s *Service) ChunkSize(size int64) {
+	s.chunkSize = size
+}
+
+// LargeThreshold sets the size in bytes above which files are uploaded
+// in chunks. Defaults to 100MB, the maximum size of a single upload
+// request.
+func (s *Service) LargeThreshold(size int64) {
+	s.largeThreshold = size
+}
+
+// readerSize returns the number of bytes left to read from r, or -1 if
+// unknown.
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case *os.File:
+		fi, err := v.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return -1
+		}
+		pos, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return fi.Size() - pos
+	}
+	return -1
+}
+
+// chunked returns true if the upload of the file described by fi, or
+// of data if not nil, must be sent in chunks: when bigger than the
+// large threshold, or when reading data of unknown size.
+func (s *Service) chunked(fi os.FileInfo, data io.Reader) bool {
+	size := int64(-1)
+	if data != nil {
+		size = readerSize(data)
+		if size < 0 {
+			return true
+		}
+	} else if fi != nil {
+		size = fi.Size()
+	}
+	return size > s.largeThreshold
+}
+
+// UploadLarge uploads a single file in chunks using Cloudinary's
+// upload_large protocol, regardless of its size. Files bigger than the
+// large threshold (100MB by default) and readers of unknown size are
+// always uploaded that way. Parameters are the same as UploadResource.
+//
+// Failing chunks are sent again according to the retry policy of the
+// service. If the upload still fails, a *LargeUploadError is returned
+// and the upload can be resumed with ResumeLarge.
+func (s *Service) UploadLarge(path string, data io.Reader, prepend string, randomPublicId bool, rtype ResourceType, opts *UploadOptions) (*UploadResult, error) {
+	return s.UploadLargeContext(context.Background(), path, data, prepend, randomPublicId, rtype, opts)
+}
+
+// UploadLargeContext is like UploadLarge but takes a context.
+func (s *Service) UploadLargeContext(ctx context.Context, path string, data io.Reader, prepend string, randomPublicId bool, rtype ResourceType, opts *UploadOptions) (*UploadResult, error) {
+	cu, err := newChunkedUpload()
+	if err != nil {
+		return nil, err
+	}
+	return s.uploadSingle(ctx, path, data, prepend, randomPublicId, rtype, opts, cu)
+}
+
+// ResumeLarge resumes an interrupted chunked upload described by e.
+// When data is nil, the file at path is read from the first byte not
+// acknowledged by the service. Otherwise, data must be positioned at
+// e.Offset. Other parameters must match the ones of the interrupted
+// upload.
+func (s *Service) ResumeLarge(e *LargeUploadError, path string, data io.Reader, prepend string, randomPublicId bool, rtype ResourceType, opts *UploadOptions) (*UploadResult, error) {
+	return s.ResumeLargeContext(context.Background(), e, path, data, prepend, randomPublicId, rtype, opts)
+}
+
+// ResumeLargeContext is like ResumeLarge but takes a context.
+func (s *Service) ResumeLargeContext(ctx context.Context, e *LargeUploadError, path string, data io.Reader, prepend string, randomPublicId bool, rtype ResourceType, opts *UploadOptions) (*UploadResult, error) {
+	cu := &chunkedUpload{id: e.UploadId, offset: e.Offset}
+	return s.uploadSingle(ctx, path, data, prepend, randomPublicId, rtype, opts, cu)
+}
+
+// postChunks sends the file content in chunks, starting at cu.offset.
+// The result of the upload is sent back by the service along with the
+// last chunk.
+func (s *Service) postChunks(ctx context.Context, rtype ResourceType, fullPath string, data io.Reader, params url.Values, cu *chunkedUpload) (*UploadResult, error) {
+	if s.simulate {
+		return nil, nil
+	}
+	if data == nil {
+		fd, err := os.Open(fullPath)
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+		if _, err := fd.Seek(cu.offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		data = fd
+		log.Printf("Uploading %s in chunks\n", fullPath)
+	}
+	size := s.chunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	r := bufio.NewReader(data)
+	// The chunk buffer only grows to the size of the data read, so that
+	// small readers of unknown size don't allocate a whole chunk.
+	var chunk bytes.Buffer
+	for {
+		chunk.Reset()
+		n, err := io.CopyN(&chunk, r, size)
+		if err != nil && err != io.EOF {
+			return nil, &LargeUploadError{cu.id, cu.offset, err}
+		}
+		// Last chunk if nothing left to read. The last chunk tells the
+		// total size, so it is not sent until then.
+		last := err == io.EOF
+		if !last {
+			if _, err := r.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return nil, &LargeUploadError{cu.id, cu.offset, err}
+			}
+		}
+		if n == 0 {
+			if cu.offset > 0 {
+				return nil, &LargeUploadError{cu.id, cu.offset, errors.New("no data left to upload")}
+			}
+			// Empty reader, sent as a regular upload
+			return s.postFile(ctx, rtype, fullPath, &chunk, params)
+		}
+		res, err := s.postChunk(ctx, rtype, fullPath, chunk.Bytes(), params, cu, last)
+		if err != nil {
+			return nil, &LargeUploadError{cu.id, cu.offset, err}
+		}
+		cu.offset += n
+		if last {
+			return res, nil
+		}
+	}
+}
+
+// postChunk sends a single chunk of a chunked upload.
+func (s *Service) postChunk(ctx context.Context, rtype ResourceType, fullPath string, chunk []byte, params url.Values, cu *chunkedUpload, last bool) (*UploadResult, error) {
+	buf := new(bytes.Buffer)
+	w := multipart.NewWriter(buf)
+	if err := writeMultipart(w, fullPath, bytes.NewReader(chunk), params); err != nil {
+		return nil, err
+	}
+
+	// Total size is unknown (-1) until the last chunk
+	total := "-1"
+	end := cu.offset + int64(len(chunk))
+	if last {
+		total = fmt.Sprintf("%d", end)
+	}
+	// Sending a chunk again is harmless
+	resp, err := s.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", s.uploadUrl(rtype), bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		req.Header.Set("X-Unique-Upload-Id", cu.id)
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", cu.offset, end-1, total))
+		return req, nil
+	}, retryAlways)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return decodeUploadResult(resp)
+}
@@ -0,0 +1,99 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// UploadFromURL asks the service to fetch and store a remote resource.
+// fileURL can be an HTTP(S) URL, or a cloud storage URL such as
+// s3://bucket/path or gs://bucket/path if the account has access to it.
+//
+// Unless randomPublicId is true or opts.PublicId is set, the public id
+// is the URL path without extension, after prepend, e.g.
+// https://example.com/images/logo.png is stored as images/logo.
+func (s *Service) UploadFromURL(fileURL, prepend string, randomPublicId bool, rtype ResourceType, opts *UploadOptions) (*UploadResult, error) {
+	return s.UploadFromURLContext(context.Background(), fileURL, prepend, randomPublicId, rtype, opts)
+}
+
+// UploadFromURLContext is like UploadFromURL but takes a context.
+func (s *Service) UploadFromURLContext(ctx context.Context, fileURL, prepend string, randomPublicId bool, rtype ResourceType, opts *UploadOptions) (*UploadResult, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	publicId := remotePublicId(u.Path, prepend)
+	if u.Scheme == "" || publicId == "" {
+		return nil, errors.New("Not a remote file URL: " + fileURL)
+	}
+	if randomPublicId {
+		publicId = ""
+	}
+	params, err := s.uploadParams(opts, publicId)
+	if err != nil {
+		return nil, err
+	}
+	return s.postRemote(ctx, rtype, fileURL, params)
+}
+
+// remotePublicId returns the public id of a remote file: its URL path
+// without extension, after prepend. It is empty if the path has no file
+// name.
+func remotePublicId(urlPath, prepend string) string {
+	if base := urlPath[strings.LastIndex(urlPath, "/")+1:]; base == "" || base == "." || base == ".." {
+		return ""
+	}
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	name = strings.TrimSuffix(name, path.Ext(name))
+	prepend = strings.TrimPrefix(strings.TrimSpace(prepend), "/")
+	if prepend != "" {
+		prepend = EnsureTrailingSlash(prepend)
+	}
+	return prepend + name
+}
+
+// UploadDataURI uploads content encoded as a base64 data URI, e.g.
+//  data:image/png;base64,iVBORw0KGgo...
+// A random public id is generated unless opts.PublicId is set.
+func (s *Service) UploadDataURI(dataURI string, rtype ResourceType, opts *UploadOptions) (*UploadResult, error) {
+	return s.UploadDataURIContext(context.Background(), dataURI, rtype, opts)
+}
+
+// UploadDataURIContext is like UploadDataURI but takes a context.
+func (s *Service) UploadDataURIContext(ctx context.Context, dataURI string, rtype ResourceType, opts *UploadOptions) (*UploadResult, error) {
+	if !strings.HasPrefix(dataURI, "data:") || !strings.Contains(dataURI, ";base64,") {
+		return nil, errors.New("Not a base64 data URI")
+	}
+	params, err := s.uploadParams(opts, "")
+	if err != nil {
+		return nil, err
+	}
+	return s.postRemote(ctx, rtype, dataURI, params)
+}
+
+// postRemote sends the upload parameters along with file, a remote URL
+// or data URI sent as a plain form field.
+func (s *Service) postRemote(ctx context.Context, rtype ResourceType, file string, params url.Values) (*UploadResult, error) {
+	if s.simulate {
+		return nil, nil
+	}
+	params.Set("file", file)
+	mode := retryRejected
+	if params.Get("public_id") != "" {
+		mode = retryAlways
+	}
+	resp, err := s.postForm(ctx, s.uploadUrl(rtype), params, mode)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return decodeUploadResult(resp)
+}
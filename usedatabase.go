@@ -0,0 +1,73 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build mongodb
+// +build mongodb
+
+package cloudinary
+
+import (
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/gotsunami/go-cloudinary/internal/mongosync"
+)
+
+// UseDatabase connects to a MongoDB database, given by a mongodb:// URI,
+// and uses it as sync store. It is only available when building with
+// the mongodb tag, so that the package doesn't depend on the MongoDB
+// driver otherwise.
+//
+// Deprecated: use UseSyncStore with a store of the mongostore package,
+// which can be closed.
+func (s *Service) UseDatabase(mongoDbURI string) error {
+	u, err := url.Parse(mongoDbURI)
+	if err != nil {
+		return err
+	}
+	if s.verbose {
+		log.Printf("Connecting to database %s/%s ... ", u.Host, strings.TrimPrefix(u.Path, "/"))
+	}
+	st, err := mongosync.Dial(mongoDbURI)
+	if err != nil {
+		return err
+	}
+	if s.verbose {
+		log.Println("Connected")
+	}
+	s.UseSyncStore(mongoStore{st})
+	return nil
+}
+
+// mongoStore is the SyncStore used by UseDatabase.
+type mongoStore struct {
+	st *mongosync.Store
+}
+
+func (m mongoStore) Get(publicId string) (*SyncEntry, error) {
+	e, err := m.st.Get(publicId)
+	return (*SyncEntry)(e), err
+}
+
+func (m mongoStore) Put(e *SyncEntry) error {
+	return m.st.Put((*mongosync.Entry)(e))
+}
+
+func (m mongoStore) Delete(publicId string) error {
+	return m.st.Delete(publicId)
+}
+
+func (m mongoStore) List() ([]*SyncEntry, error) {
+	entries, err := m.st.List()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*SyncEntry, len(entries))
+	for i, e := range entries {
+		list[i] = (*SyncEntry)(e)
+	}
+	return list, nil
+}
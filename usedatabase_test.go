@@ -0,0 +1,30 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build mongodb
+// +build mongodb
+
+package cloudinary
+
+import (
+	"testing"
+)
+
+func TestUseDatabase(t *testing.T) {
+	s := new(Service)
+	if err := s.UseDatabase("baduri::"); err == nil {
+		t.Error("should fail on bad uri")
+	}
+	// Bad scheme
+	if err := s.UseDatabase("http://localhost"); err == nil {
+		t.Error("should fail if URL scheme different from mongodb://")
+	}
+	if err := s.UseDatabase("mongodb://localhost/cloudinary"); err != nil {
+		t.Error("please ensure you have a running MongoDB server on localhost")
+	}
+	if s.syncStore == nil {
+		t.Error("service's sync store should not be nil")
+	}
+}